@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/pkg/errors"
+	"github.com/steemit/steemutil/protocol"
 )
 
 // AccountHistoryEntry models a single element of a
@@ -13,7 +14,8 @@ import (
 // is an object whose "op" field is [type, payload] and whose "timestamp" field
 // is an ISO8601 string. Example:
 //
-//	[42, {"op":["transfer",{"from":"a","to":"b","amount":"1.000 STEEM"}],"timestamp":"2026-01-01T00:00:00Z"}]
+//	[42, {"trx_id":"0123abcd","block":1000,"trx_in_block":0,"op_in_trx":0,"virtual_op":0,
+//	      "op":["transfer",{"from":"a","to":"b","amount":"1.000 STEEM"}],"timestamp":"2026-01-01T00:00:00Z"}]
 //
 // The custom UnmarshalJSON flattens this tuple into typed fields so callers can
 // work with Index/Op/Timestamp directly rather than nested raw arrays.
@@ -21,15 +23,34 @@ type AccountHistoryEntry struct {
 	Index     int64
 	Op        AccountHistoryOp
 	Timestamp string
+
+	// Position of the operation on the chain. Virtual operations carry an
+	// all-zero TransactionID and a non-zero VirtualOperation.
+	TransactionID          string
+	BlockNumber            uint32
+	TransactionInBlock     uint32
+	OperationInTransaction uint16
+	VirtualOperation       uint64
 }
 
 // AccountHistoryOp is the [type, payload] pair carried in each account-history
-// entry's "op" field. Payload is kept as a generic map because operation
-// payloads vary by type (e.g. transfer carries to/amount/memo, vote carries
-// voter/author/permlink/weight).
+// entry's "op" field. The payload is available three ways: Data decodes it into
+// the operation's protocol struct (see DecodeOperation), Payload as a generic
+// map, and Raw as the JSON the node sent.
+//
+//	switch op := e.Op.Data.(type) {
+//	case *protocol.TransferOperation:
+//		fmt.Println(op.From, op.To, op.Amount)
+//	case *api.AuthorRewardOperation:
+//		fmt.Println(op.Author, op.VestingPayout)
+//	case *api.RawOperation:
+//		fmt.Println("unknown operation", op.OpType, string(op.Payload))
+//	}
 type AccountHistoryOp struct {
 	Type    string
 	Payload map[string]interface{}
+	Data    protocol.Operation
+	Raw     json.RawMessage
 }
 
 // UnmarshalJSON parses the wire form [index, {"op":[type, payload], "timestamp": ts}].
@@ -52,16 +73,26 @@ func (e *AccountHistoryEntry) UnmarshalJSON(data []byte) error {
 		return errors.Wrap(err, "invalid account_history index")
 	}
 
-	// tuple[1] -> body object with "op" and "timestamp"
+	// tuple[1] -> body object with "op", "timestamp" and the chain position
 	var body struct {
-		Op        []json.RawMessage `json:"op"`
-		Timestamp string            `json:"timestamp"`
+		Op                     []json.RawMessage `json:"op"`
+		Timestamp              string            `json:"timestamp"`
+		TransactionID          string            `json:"trx_id"`
+		BlockNumber            uint32            `json:"block"`
+		TransactionInBlock     uint32            `json:"trx_in_block"`
+		OperationInTransaction uint16            `json:"op_in_trx"`
+		VirtualOperation       uint64            `json:"virtual_op"`
 	}
 	if err := json.Unmarshal(tuple[1], &body); err != nil {
 		return errors.Wrap(err, "invalid account_history body")
 	}
 
 	e.Timestamp = body.Timestamp
+	e.TransactionID = body.TransactionID
+	e.BlockNumber = body.BlockNumber
+	e.TransactionInBlock = body.TransactionInBlock
+	e.OperationInTransaction = body.OperationInTransaction
+	e.VirtualOperation = body.VirtualOperation
 
 	if len(body.Op) != 2 {
 		return errors.Errorf("account_history op must have exactly 2 elements [type, payload], got %d", len(body.Op))
@@ -74,6 +105,8 @@ func (e *AccountHistoryEntry) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(body.Op[1], &e.Op.Payload); err != nil {
 		return errors.Wrap(err, "invalid account_history op payload")
 	}
+	e.Op.Raw = body.Op[1]
+	e.Op.Data = DecodeOperation(protocol.OpType(e.Op.Type), body.Op[1])
 
 	return nil
 }
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	protocolapi "github.com/steemit/steemutil/protocol/api"
+)
+
+// MaxLookupAccounts is the largest page condenser_api.lookup_accounts serves.
+const MaxLookupAccounts = 1000
+
+// accountsChunk is the number of names GetAccountsBulk asks get_accounts for
+// in one request.
+const accountsChunk = 100
+
+// AccountNameIterator walks account names in lexical order. It is not safe
+// for concurrent use.
+type AccountNameIterator struct {
+	ctx      context.Context
+	a        *API
+	prefix   string
+	pageSize int
+	lower    string
+	more     bool
+	paged    bool
+	buf      []string
+	name     string
+	err      error
+}
+
+// IterateAccountNames walks every account name starting with prefix (all
+// names if prefix is empty) in lexical order, fetching pages of pageSize
+// names (MaxLookupAccounts if pageSize <= 0 or larger) as they are needed:
+//
+//	it := a.IterateAccountNames(ctx, "steem", 0)
+//	for it.Next() {
+//		fmt.Println(it.Name())
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Each page starts at the last name of the previous one; that repeated name
+// is skipped.
+func (a *API) IterateAccountNames(ctx context.Context, prefix string, pageSize int) *AccountNameIterator {
+	if pageSize <= 0 || pageSize > MaxLookupAccounts {
+		pageSize = MaxLookupAccounts
+	}
+	if pageSize < 2 {
+		pageSize = 2 // a page must hold more than the boundary name
+	}
+	return &AccountNameIterator{ctx: ctx, a: a, prefix: prefix, pageSize: pageSize, lower: prefix, more: true}
+}
+
+// Next advances to the next name. It returns false when every name was
+// visited or a page failed; check Err afterwards.
+func (it *AccountNameIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.err != nil || !it.more {
+			return false
+		}
+		it.err = it.fetchPage()
+	}
+	it.name = it.buf[0]
+	it.buf = it.buf[1:]
+	return true
+}
+
+// Name returns the name Next advanced to.
+func (it *AccountNameIterator) Name() string {
+	return it.name
+}
+
+// Err returns the error that ended the walk, if any.
+func (it *AccountNameIterator) Err() error {
+	return it.err
+}
+
+func (it *AccountNameIterator) fetchPage() error {
+	page, err := it.a.LookupAccountsContext(it.ctx, it.lower, it.pageSize)
+	if err != nil {
+		return errors.Wrapf(err, "failed to page account names from %q", it.lower)
+	}
+	// A short page is the last one.
+	it.more = len(page) == it.pageSize
+	if it.paged && len(page) > 0 && page[0] == it.lower {
+		page = page[1:] // the boundary name ended the previous page
+	}
+	if len(page) > 0 {
+		it.lower = page[len(page)-1]
+	} else {
+		it.more = false
+	}
+	// Names are sorted, so the first one without the prefix ends the walk.
+	for i, name := range page {
+		if !strings.HasPrefix(name, it.prefix) {
+			page, it.more = page[:i], false
+			break
+		}
+	}
+	it.paged = true
+	it.buf = page
+	return nil
+}
+
+// EachAccount hands the account of every name starting with prefix to fn, in
+// name order. Names are enumerated a page at a time, and each page is fetched
+// with GetAccountsBulk. Returning an error from fn stops the walk.
+func (a *API) EachAccount(ctx context.Context, prefix string, fn func(*protocolapi.ExtendedAccount) error) error {
+	it := a.IterateAccountNames(ctx, prefix, 0)
+	names := make([]string, 0, MaxLookupAccounts)
+	flush := func() error {
+		accounts, _, err := a.GetAccountsBulkContext(ctx, names)
+		if err != nil {
+			return err
+		}
+		names = names[:0]
+		for _, account := range accounts {
+			if err := fn(account); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for it.Next() {
+		names = append(names, it.Name())
+		if len(names) == cap(names) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// GetAccountsBulk is like GetAccounts for name lists of any size. See
+// GetAccountsBulkContext.
+func (a *API) GetAccountsBulk(names []string) ([]*protocolapi.ExtendedAccount, []string, error) {
+	return a.GetAccountsBulkContext(context.Background(), names)
+}
+
+// GetAccountsBulkContext fetches the accounts of names with one get_accounts
+// request per accountsChunk names, WithConcurrency requests at once. Repeated
+// names are fetched once. It returns the accounts found, in the order their
+// names first appear in names, and the names that match no account.
+func (a *API) GetAccountsBulkContext(ctx context.Context, names []string) ([]*protocolapi.ExtendedAccount, []string, error) {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chunks := make([][]*protocolapi.ExtendedAccount, (len(unique)+accountsChunk-1)/accountsChunk)
+	sem := make(chan struct{}, a.concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := range chunks {
+		start := i * accountsChunk
+		end := start + accountsChunk
+		if end > len(unique) {
+			end = len(unique)
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, chunk []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			accounts, err := a.GetAccountsContext(ctx, chunk)
+			if err != nil {
+				once.Do(func() {
+					firstErr = errors.Wrapf(err, "failed to get accounts %s..%s", chunk[0], chunk[len(chunk)-1])
+					cancel()
+				})
+				return
+			}
+			chunks[i] = accounts
+		}(i, unique[start:end])
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// get_accounts leaves out unknown names, so match the results by name.
+	found := make(map[string]*protocolapi.ExtendedAccount, len(unique))
+	for _, chunk := range chunks {
+		for _, account := range chunk {
+			found[account.Name] = account
+		}
+	}
+	accounts := make([]*protocolapi.ExtendedAccount, 0, len(found))
+	var missing []string
+	for _, name := range unique {
+		if account, ok := found[name]; ok {
+			accounts = append(accounts, account)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	return accounts, missing, nil
+}
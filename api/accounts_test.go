@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/steemit/steemgosdk/steemtest"
+	protocolapi "github.com/steemit/steemutil/protocol/api"
+)
+
+// accountsNode returns a node with an account for each of names.
+func accountsNode(t *testing.T, names []string) *steemtest.Node {
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	for _, name := range names {
+		node.AddAccount(name, nil)
+	}
+	return node
+}
+
+// getAccountsSizes returns the number of names of every get_accounts request
+// node received.
+func getAccountsSizes(node *steemtest.Node) []int {
+	var sizes []int
+	for _, req := range node.Requests() {
+		if req.Method != "condenser_api.get_accounts" {
+			continue
+		}
+		var params [][]string
+		json.Unmarshal(req.Params, &params)
+		sizes = append(sizes, len(params[0]))
+	}
+	return sizes
+}
+
+func TestIterateAccountNames(t *testing.T) {
+	names := []string{"alice", "bob", "steem", "steemit", "steemit2", "steemy", "steemz", "zed"}
+	api := NewAPI(accountsNode(t, names).URL)
+
+	it := api.IterateAccountNames(context.Background(), "steem", 2)
+	var got []string
+	for it.Next() {
+		got = append(got, it.Name())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if want := "steem steemit steemit2 steemy steemz"; strings.Join(got, " ") != want {
+		t.Errorf("expected %s, got %v", want, got)
+	}
+
+	it = api.IterateAccountNames(context.Background(), "", 3)
+	got = got[:0]
+	for it.Next() {
+		got = append(got, it.Name())
+	}
+	if it.Err() != nil || strings.Join(got, " ") != strings.Join(names, " ") {
+		t.Errorf("expected all names, got %v (%v)", got, it.Err())
+	}
+}
+
+func TestEachAccount(t *testing.T) {
+	var names []string
+	for i := 0; i < 1234; i++ {
+		names = append(names, fmt.Sprintf("user%04d", i))
+	}
+	node := accountsNode(t, append(names, "zed"))
+	api := NewAPI(node.URL, WithConcurrency(3))
+
+	var got []string
+	err := api.EachAccount(context.Background(), "user", func(account *protocolapi.ExtendedAccount) error {
+		got = append(got, account.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachAccount failed: %v", err)
+	}
+	if strings.Join(got, " ") != strings.Join(names, " ") {
+		t.Fatalf("expected %d accounts in order, got %d", len(names), len(got))
+	}
+	for _, n := range getAccountsSizes(node) {
+		if n > accountsChunk {
+			t.Errorf("get_accounts asked for %d names", n)
+		}
+	}
+}
+
+func TestGetAccountsBulk(t *testing.T) {
+	var names []string
+	for i := 0; i < 250; i++ {
+		names = append(names, fmt.Sprintf("user%03d", i))
+	}
+	node := accountsNode(t, names)
+	api := NewAPI(node.URL, WithConcurrency(2))
+
+	// Reverse order, with repeats and unknown names mixed in.
+	var request []string
+	for i := len(names) - 1; i >= 0; i-- {
+		request = append(request, names[i])
+		if i%50 == 0 {
+			request = append(request, names[i], fmt.Sprintf("ghost%d", i))
+		}
+	}
+	accounts, missing, err := api.GetAccountsBulk(request)
+	if err != nil {
+		t.Fatalf("GetAccountsBulk failed: %v", err)
+	}
+	if len(accounts) != len(names) {
+		t.Fatalf("expected %d accounts, got %d", len(names), len(accounts))
+	}
+	for i, account := range accounts {
+		if want := names[len(names)-1-i]; account.Name != want {
+			t.Fatalf("position %d: got %s, want %s", i, account.Name, want)
+		}
+	}
+	if want := "ghost200 ghost150 ghost100 ghost50 ghost0"; strings.Join(missing, " ") != want {
+		t.Errorf("expected missing %s, got %v", want, missing)
+	}
+	// 255 unique names in chunks of 100.
+	sizes := getAccountsSizes(node)
+	sort.Ints(sizes)
+	if fmt.Sprint(sizes) != "[55 100 100]" {
+		t.Errorf("unexpected request sizes %v", sizes)
+	}
+}
+
+func TestGetAccountsBulk_Error(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{ID: req.ID, Error: json.RawMessage(`{"code":-32602,"message":"Invalid parameters"}`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport), fastRetry(1))
+
+	if _, _, err := api.GetAccountsBulk([]string{"alice", "bob"}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
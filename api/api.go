@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
-	"github.com/steemit/steemutil/jsonrpc2"
 	"github.com/steemit/steemutil/protocol"
 	protocolapi "github.com/steemit/steemutil/protocol/api"
 	"github.com/steemit/steemutil/rpc"
@@ -14,10 +17,23 @@ import (
 )
 
 // API provides methods to call Steem RPC APIs.
+//
+// Every RPC method has a context-aware variant suffixed with Context (e.g.
+// CallContext, GetBlockContext). The context is handed to the Transport, so
+// cancelling it or letting its deadline pass aborts the in-flight node
+// request. The variants without a context use context.Background().
 type API struct {
-	url      string
-	maxRetry int
-	seqNo    int // Sequence number for RPC requests
+	url          string
+	retry        RetryPolicy
+	batchSize    int    // GetBlocks/GetOpsInBlocks batch size; 0 = one request per block
+	concurrency  int    // GetBlocks/GetOpsInBlocks requests in flight at once
+	legacy       bool   // send the legacy "call" wire form instead of "api.method"
+	seqNo        int64  // signed-call sequence number, accessed atomically
+	reqID        uint64 // JSON-RPC id counter, accessed atomically
+	transport    Transport
+	interceptors []Interceptor
+	logger       Logger
+	metrics      Metrics
 }
 
 // WrapBlock represents a block with its block number.
@@ -33,57 +49,218 @@ type WrapOpsInBlock struct {
 }
 
 // NewAPI creates a new API instance.
-func NewAPI(url string) *API {
-	return &API{
-		url:      url,
-		maxRetry: 5, // default max retry
+//
+// By default requests are posted to url over HTTP (see HTTPTransport), or
+// sent over a single persistent connection when url is ws:// or wss:// (see
+// WebSocketTransport). opts can replace the transport or tune it (see
+// WithTransport, WithHTTPClient).
+func NewAPI(url string, opts ...Option) *API {
+	a := &API{
+		url:         url,
+		retry:       DefaultRetryPolicy(),
+		concurrency: DefaultConcurrency,
+		transport:   defaultTransport(url),
+		logger:      NopLogger{},
+		metrics:     NopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// defaultTransport picks the transport implied by the URL scheme.
+func defaultTransport(url string) Transport {
+	if isWebSocketURL(url) {
+		return NewWebSocketTransport(url)
 	}
+	return NewHTTPTransport(url, nil)
+}
+
+func isWebSocketURL(url string) bool {
+	return strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://")
 }
 
-// SetMaxRetry sets the maximum number of retries for API calls.
+// Close releases resources held by the transport, such as the persistent
+// connection of a WebSocketTransport. It is a no-op for HTTP transports.
+func (a *API) Close() error {
+	if closer, ok := a.transport.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SetMaxRetry sets the maximum number of retries for API calls; 0 disables
+// retrying. The backoff settings of the retry policy are kept.
 func (a *API) SetMaxRetry(maxRetry int) {
-	a.maxRetry = maxRetry
+	a.retry.MaxRetries = maxRetry
+}
+
+// RetryPolicy returns the policy applied to every request.
+func (a *API) RetryPolicy() RetryPolicy {
+	return a.retry
+}
+
+// send hands a JSON-RPC 2.0 request through the interceptors to the
+// configured Transport, retrying transient failures according to the retry
+// policy. Params are passed through untouched so the caller controls the wire
+// shape.
+func (a *API) send(ctx context.Context, method string, params interface{}) (*RPCResponse, error) {
+	return a.intercept(ctx, a.newRequest(method, params), func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		method := callName(req.Method, req.Params)
+		return a.withRetry(ctx, method, isBroadcastMethod(method), func() (*RPCResponse, error) {
+			return a.transport.Send(ctx, req)
+		})
+	})
+}
+
+// wireCall returns the JSON-RPC method and params that invoke apiName.method
+// in the configured wire form: the dotted "api.method" form, or with
+// WithLegacyCallForm the "call" form that packs [api, method, params] into
+// the params array.
+func (a *API) wireCall(apiName, method string, params interface{}) (string, interface{}) {
+	if a.legacy {
+		return "call", []interface{}{apiName, method, params}
+	}
+	return fmt.Sprintf("%s.%s", apiName, method), params
+}
+
+// newRequest builds a JSON-RPC 2.0 envelope with a fresh id.
+func (a *API) newRequest(method string, params interface{}) *RPCRequest {
+	return &RPCRequest{
+		JsonRpc: "2.0",
+		ID:      atomic.AddUint64(&a.reqID, 1),
+		Method:  method,
+		Params:  params,
+	}
+}
+
+// withRetry runs attempt, a request for method, until it succeeds, fails
+// permanently or the retry policy is exhausted, sleeping with backoff in
+// between. broadcast marks a request that pushes a transaction to the chain,
+// which is only resent when it provably never arrived. Every retry is logged
+// at warn level and counted in the metrics.
+//
+// The last response or error is returned once retries are exhausted, so a
+// node-side RPC error still reaches the caller as an RPC error.
+func (a *API) withRetry(ctx context.Context, method string, broadcast bool, attempt func() (*RPCResponse, error)) (*RPCResponse, error) {
+	for n := 0; ; n++ {
+		resp, err := attempt()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if n >= a.retry.MaxRetries || !a.retry.retryable(broadcast, resp, err) {
+			if err != nil && n > 0 {
+				err = errors.Wrapf(err, "gave up after %d attempt(s)", n+1)
+			}
+			return resp, err
+		}
+		a.metrics.ObserveRetry(method)
+		backoff := a.retry.Backoff(n + 1)
+		if a.logger.Enabled(ctx, LevelWarn) {
+			reason := interface{}(err)
+			if err == nil {
+				reason = string(resp.Error)
+			}
+			a.logger.Log(ctx, LevelWarn, "retrying request",
+				"method", method, "attempt", n+1, "backoff", backoff, "error", reason)
+		}
+		if err := sleepContext(ctx, backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// toResultData converts a Transport response into the steemutil envelope that
+// Call and SignedCall have always returned.
+func toResultData(resp *RPCResponse) (*protocolapi.RpcResultData, error) {
+	result := &protocolapi.RpcResultData{
+		Id:      protocol.UInt(resp.ID),
+		JsonRpc: resp.JsonRpc,
+	}
+	if len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, &result.Result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 // Call makes a generic RPC call to the specified API and method.
 func (a *API) Call(apiName, method string, params []interface{}) (*protocolapi.RpcResultData, error) {
-	rpc := jsonrpc2.NewClient(a.url)
-	fullMethod := fmt.Sprintf("%s.%s", apiName, method)
+	return a.CallContext(context.Background(), apiName, method, params)
+}
 
-	err := rpc.BuildSendData(fullMethod, params)
+// CallContext is like Call but aborts the request when ctx is done.
+func (a *API) CallContext(ctx context.Context, apiName, method string, params []interface{}) (*protocolapi.RpcResultData, error) {
+	rpcResponse, err := a.call(ctx, apiName, method, params)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to build RPC data for %s", fullMethod)
+		return nil, err
 	}
+	return toResultData(rpcResponse)
+}
 
-	rpcResponse, err := rpc.Send()
+// call sends apiName.method and turns a node error into an *RPCError. The
+// response result is left undecoded.
+func (a *API) call(ctx context.Context, apiName, method string, params interface{}) (_ *RPCResponse, err error) {
+	fullMethod := fmt.Sprintf("%s.%s", apiName, method)
+	defer a.observeCall(fullMethod, time.Now(), &err)
+
+	wireMethod, wireParams := a.wireCall(apiName, method, params)
+	rpcResponse, err := a.send(ctx, wireMethod, wireParams)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to send RPC request for %s", fullMethod)
 	}
 
-	if rpcResponse.Error != nil {
-		return nil, errors.Errorf("RPC error for %s: %v", fullMethod, rpcResponse.Error)
+	if rpcResponse.HasError() {
+		return nil, newRPCError(fullMethod, rpcResponse.Error)
 	}
-
 	return rpcResponse, nil
 }
 
+// decodeResult unmarshals a raw RPC result into result. An absent result
+// leaves result untouched.
+func decodeResult(raw json.RawMessage, result interface{}) error {
+	if result == nil || len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return errors.Wrap(err, "failed to unmarshal RPC result")
+	}
+	return nil
+}
+
 // SignedCall makes a signed RPC call to the specified API method.
 // This method is used for authenticated API calls that require proof of account ownership.
 // Only HTTP transport is supported for signed calls.
 func (a *API) SignedCall(method string, params []interface{}, account string, privateKey string) (*protocolapi.RpcResultData, error) {
+	return a.SignedCallContext(context.Background(), method, params, account, privateKey)
+}
+
+// SignedCallContext is like SignedCall but aborts the request when ctx is done.
+func (a *API) SignedCallContext(ctx context.Context, method string, params []interface{}, account string, privateKey string) (*protocolapi.RpcResultData, error) {
+	rpcResponse, err := a.signedCall(ctx, method, params, account, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return toResultData(rpcResponse)
+}
+
+// signedCall signs and sends method, leaving the response result undecoded.
+func (a *API) signedCall(ctx context.Context, method string, params []interface{}, account string, privateKey string) (_ *RPCResponse, err error) {
+	defer a.observeCall(method, time.Now(), &err)
+
 	// Validate that we're using HTTP transport
 	if err := a.validateTransportForSignedCall(); err != nil {
 		return nil, err
 	}
 
-	// Increment sequence number for unique request ID
-	a.seqNo++
-
-	// Create RPC request
+	// Create RPC request with a unique sequence number, safe under
+	// concurrent signed calls
 	request := &rpc.RpcRequest{
 		Method: method,
 		Params: params,
-		ID:     a.seqNo,
+		ID:     int(atomic.AddInt64(&a.seqNo, 1)),
 	}
 
 	// Sign the request
@@ -92,27 +269,19 @@ func (a *API) SignedCall(method string, params []interface{}, account string, pr
 		return nil, errors.Wrapf(err, "failed to sign request for method %s", method)
 	}
 
-	// Create JSON-RPC client
-	rpcClient := jsonrpc2.NewClient(a.url)
-
 	// Marshal signed request to get the params
 	signedParams := map[string]interface{}{
 		"__signed": signedRequest.Params.Signed,
 	}
 
-	// Build and send the signed request
-	err = rpcClient.BuildSendData(method, []interface{}{signedParams})
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to build signed RPC data for %s", method)
-	}
-
-	rpcResponse, err := rpcClient.Send()
+	// Send the signed request
+	rpcResponse, err := a.send(ctx, method, []interface{}{signedParams})
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to send signed RPC request for %s", method)
 	}
 
-	if rpcResponse.Error != nil {
-		return nil, errors.Errorf("signed RPC error for %s: %v", method, rpcResponse.Error)
+	if rpcResponse.HasError() {
+		return nil, errors.Wrap(newRPCError(method, rpcResponse.Error), "signed call failed")
 	}
 
 	return rpcResponse, nil
@@ -120,18 +289,18 @@ func (a *API) SignedCall(method string, params []interface{}, account string, pr
 
 // SignedCallWithResult makes a signed RPC call and unmarshals the result into the provided result object.
 func (a *API) SignedCallWithResult(method string, params []interface{}, account string, privateKey string, result interface{}) error {
-	rpcResponse, err := a.SignedCall(method, params, account, privateKey)
-	if err != nil {
-		return err
-	}
+	return a.SignedCallWithResultContext(context.Background(), method, params, account, privateKey, result)
+}
 
-	resultBytes, err := json.Marshal(rpcResponse.Result)
+// SignedCallWithResultContext is like SignedCallWithResult but aborts the request when ctx is done.
+func (a *API) SignedCallWithResultContext(ctx context.Context, method string, params []interface{}, account string, privateKey string, result interface{}) error {
+	rpcResponse, err := a.signedCall(ctx, method, params, account, privateKey)
 	if err != nil {
-		return errors.Wrapf(err, "failed to marshal signed RPC result for %s", method)
+		return err
 	}
 
-	if err := json.Unmarshal(resultBytes, result); err != nil {
-		return errors.Wrapf(err, "failed to unmarshal signed RPC result for %s", method)
+	if err := decodeResult(rpcResponse.Result, result); err != nil {
+		return errors.Wrapf(err, "failed to decode signed RPC result for %s", method)
 	}
 
 	return nil
@@ -144,198 +313,165 @@ func (a *API) validateTransportForSignedCall() error {
 	if !strings.HasPrefix(a.url, "http://") && !strings.HasPrefix(a.url, "https://") {
 		return errors.New("signed calls can only be made when using HTTP transport")
 	}
+	// An explicitly installed WebSocket transport is rejected too, whatever the URL.
+	if !supportsSignedCall(a.transport) {
+		return errors.New("signed calls can only be made when using HTTP transport")
+	}
 	return nil
 }
 
+// supportsSignedCall reports whether signed requests may go through t: a
+// WebSocket transport cannot carry them, nor can a pool that contains one or
+// a cache in front of either.
+func supportsSignedCall(t Transport) bool {
+	switch t := t.(type) {
+	case *WebSocketTransport:
+		return false
+	case *Cache:
+		return supportsSignedCall(t.next)
+	case *Pool:
+		for _, n := range t.nodes {
+			if !supportsSignedCall(n.transport) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // CallWithResult makes an RPC call and unmarshals the result into the provided result object.
 func (a *API) CallWithResult(apiName, method string, params []interface{}, result interface{}) error {
-	rpcResponse, err := a.Call(apiName, method, params)
+	return a.CallWithResultContext(context.Background(), apiName, method, params, result)
+}
+
+// CallWithResultContext is like CallWithResult but aborts the request when ctx is done.
+// The result is decoded straight from the response bytes.
+func (a *API) CallWithResultContext(ctx context.Context, apiName, method string, params []interface{}, result interface{}) error {
+	rpcResponse, err := a.call(ctx, apiName, method, params)
 	if err != nil {
 		return err
 	}
+	return decodeResult(rpcResponse.Result, result)
+}
 
-	// Marshal and unmarshal to convert to the target type
-	tmp, err := json.Marshal(rpcResponse.Result)
-	if err != nil {
-		return errors.Wrap(err, "failed to marshal RPC result")
-	}
+// CallNamed calls an appbase API method that takes a named-object params
+// body, such as database_api, block_api, account_history_api or rc_api, and
+// decodes the result into result.
+//
+// params is marshalled as a JSON object (a struct or map); nil sends {}.
+//
+//	var out struct {
+//		Accounts []protocolapi.ExtendedAccount `json:"accounts"`
+//	}
+//	err := a.CallNamed("database_api", "find_accounts",
+//		map[string]interface{}{"accounts": []string{"steemit"}}, &out)
+func (a *API) CallNamed(apiName, method string, params interface{}, result interface{}) error {
+	return a.CallNamedContext(context.Background(), apiName, method, params, result)
+}
 
-	if err := json.Unmarshal(tmp, result); err != nil {
-		return errors.Wrap(err, "failed to unmarshal RPC result")
+// CallNamedContext is like CallNamed but aborts the request when ctx is done.
+func (a *API) CallNamedContext(ctx context.Context, apiName, method string, params interface{}, result interface{}) error {
+	if params == nil {
+		params = struct{}{}
 	}
-
-	return nil
+	rpcResponse, err := a.call(ctx, apiName, method, params)
+	if err != nil {
+		return err
+	}
+	return decodeResult(rpcResponse.Result, result)
 }
 
 // GetDynamicGlobalProperties gets the dynamic global properties from the Steem blockchain.
 func (a *API) GetDynamicGlobalProperties() (dgp *protocolapi.DynamicGlobalProperties, err error) {
-	rpc := jsonrpc2.NewClient(a.url)
-	err = rpc.BuildSendData(
-		"condenser_api.get_dynamic_global_properties",
-		[]any{},
-	)
-	if err != nil {
-		return
-	}
-	rpcResponse, err := rpc.Send()
-	if err != nil {
-		return
-	}
-	if rpcResponse.Error != nil {
-		return dgp, errors.Errorf("failed to GetDynamicGlobalProperties:%v\n", rpcResponse.Error)
-	}
-	tmp, err := json.Marshal(rpcResponse.Result)
+	return a.GetDynamicGlobalPropertiesContext(context.Background())
+}
+
+// GetDynamicGlobalPropertiesContext is like GetDynamicGlobalProperties but aborts the request when ctx is done.
+func (a *API) GetDynamicGlobalPropertiesContext(ctx context.Context) (dgp *protocolapi.DynamicGlobalProperties, err error) {
+	result, err := CallTyped[protocolapi.DynamicGlobalProperties](ctx, a, "condenser_api", "get_dynamic_global_properties", []interface{}{})
 	if err != nil {
-		return
+		return nil, errors.Wrap(err, "failed to GetDynamicGlobalProperties")
 	}
-	dgp = &protocolapi.DynamicGlobalProperties{}
-	json.Unmarshal(tmp, dgp)
-	return
+	return &result, nil
 }
 
 // GetBlock gets a block by block number.
 func (a *API) GetBlock(blockNum uint) (block *protocolapi.Block, err error) {
-	rpc := jsonrpc2.NewClient(a.url)
-	err = rpc.BuildSendData(
-		"condenser_api.get_block",
-		[]any{blockNum},
-	)
-	if err != nil {
-		return
-	}
-	rpcResponse, err := rpc.Send()
-	if err != nil {
-		return
-	}
-	if rpcResponse.Error != nil {
-		return block, errors.Errorf("failed to GetBlock:%v\n", rpcResponse.Error)
-	}
-	tmp, err := json.Marshal(rpcResponse.Result)
-	if err != nil {
-		return
-	}
-	block = &protocolapi.Block{}
-	json.Unmarshal(tmp, block)
-	return
+	return a.GetBlockContext(context.Background(), blockNum)
 }
 
-// wrapGetBlock is a helper function that gets a block with retry logic.
-// This will ALWAYS eventually return, at all costs (similar to steem-python's reliable_query).
-func (a *API) wrapGetBlock(blockNum uint, ch chan<- *WrapBlock) {
-	var (
-		err   error
-		block *protocolapi.Block
-	)
-
-	for {
-		block, err = a.GetBlock(blockNum)
-		if err == nil {
-			break
-		}
-		fmt.Printf("Retry get block {%+v}: %v\n", blockNum, err)
-	}
-	ch <- &WrapBlock{
-		BlockNum: blockNum,
-		Block:    block,
+// GetBlockContext is like GetBlock but aborts the request when ctx is done.
+func (a *API) GetBlockContext(ctx context.Context, blockNum uint) (block *protocolapi.Block, err error) {
+	result, err := CallTyped[protocolapi.Block](ctx, a, "condenser_api", "get_block", []interface{}{blockNum})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to GetBlock")
 	}
+	return &result, nil
 }
 
 // GetBlocks gets multiple blocks in the range [from, to).
 func (a *API) GetBlocks(from, to uint) (blocks []*WrapBlock, err error) {
-	// check params
+	return a.GetBlocksContext(context.Background(), from, to)
+}
+
+// GetBlocksContext is like GetBlocks but aborts the outstanding requests and
+// returns ctx.Err() when ctx is done.
+//
+// At most WithConcurrency requests are in flight at once, and with
+// WithBatchSize the range is fetched in JSON-RPC batches instead of one
+// request per block. Use EachBlock to process very large ranges without
+// holding every block in memory.
+//
+// If a block still cannot be fetched after the retry policy is exhausted,
+// the remaining requests are cancelled and a *BlockRangeError is returned
+// together with the blocks before the failed one.
+func (a *API) GetBlocksContext(ctx context.Context, from, to uint) (blocks []*WrapBlock, err error) {
 	if from >= to {
 		return blocks, errors.Errorf("unexpected params {from: %v}, {to: %v}\n", from, to)
 	}
-	// init
-	ch := make(chan *WrapBlock, to-from)
-	blocksMap := make(map[uint]*WrapBlock, to-from)
 	blocks = make([]*WrapBlock, 0, to-from)
-	// get blocks
-	for i := from; i < to; i++ {
-		go a.wrapGetBlock(i, ch)
-	}
-	// get results
-	for i := from; i < to; i++ {
-		result := <-ch
-		blocksMap[result.BlockNum] = result
-		if blocksMap[result.BlockNum] == nil {
-			return blocks, errors.Errorf("get block {%v} error\n", result.BlockNum)
+	err = a.EachBlock(ctx, from, to, func(b *WrapBlock) error {
+		blocks = append(blocks, b)
+		return nil
+	})
+	if err != nil {
+		var rangeErr *BlockRangeError
+		if errors.As(err, &rangeErr) {
+			return blocks, err
 		}
+		return nil, err
 	}
-	// sort result
-	for i := from; i < to; i++ {
-		blocks = append(blocks, blocksMap[i])
-	}
-	return
+	return blocks, nil
 }
 
 // GetTransactionHex gets the hexadecimal representation of a transaction.
 func (a *API) GetTransactionHex(tx *transaction.SignedTransaction) (result any, err error) {
-	rpc := jsonrpc2.NewClient(a.url)
-	err = rpc.BuildSendData(
-		"condenser_api.get_transaction_hex",
-		[]any{tx.Transaction},
-	)
-	if err != nil {
-		return
-	}
-	rpcResponse, err := rpc.Send()
+	return a.GetTransactionHexContext(context.Background(), tx)
+}
+
+// GetTransactionHexContext is like GetTransactionHex but aborts the request when ctx is done.
+func (a *API) GetTransactionHexContext(ctx context.Context, tx *transaction.SignedTransaction) (result any, err error) {
+	rpcResponse, err := a.CallContext(ctx, "condenser_api", "get_transaction_hex", []interface{}{tx.Transaction})
 	if err != nil {
-		return
-	}
-	if rpcResponse.Error != nil {
-		return result, errors.Errorf("failed to GetTransactionHex:%v\n", rpcResponse.Error)
+		return nil, errors.Wrap(err, "failed to GetTransactionHex")
 	}
-	result = rpcResponse.Result
-	return
+	return rpcResponse.Result, nil
 }
 
 // GetOpsInBlock gets operations in a block by block number.
 // If onlyVirtual is false, returns all operations (both regular and virtual).
 // If onlyVirtual is true, returns only virtual operations.
 func (a *API) GetOpsInBlock(blockNum uint, onlyVirtual bool) (ops []*protocol.OperationObject, err error) {
-	rpc := jsonrpc2.NewClient(a.url)
-	err = rpc.BuildSendData(
-		"condenser_api.get_ops_in_block",
-		[]any{blockNum, onlyVirtual},
-	)
-	if err != nil {
-		return
-	}
-	rpcResponse, err := rpc.Send()
-	if err != nil {
-		return
-	}
-	if rpcResponse.Error != nil {
-		return ops, errors.Errorf("failed to GetOpsInBlock:%v\n", rpcResponse.Error)
-	}
-	tmp, err := json.Marshal(rpcResponse.Result)
-	if err != nil {
-		return
-	}
-	err = json.Unmarshal(tmp, &ops)
-	return
+	return a.GetOpsInBlockContext(context.Background(), blockNum, onlyVirtual)
 }
 
-// wrapGetOpsInBlock is a helper function that gets operations in a block with retry logic.
-// This will ALWAYS eventually return, at all costs (similar to steem-python's reliable_query).
-func (a *API) wrapGetOpsInBlock(blockNum uint, onlyVirtual bool, ch chan<- *WrapOpsInBlock) {
-	var (
-		err error
-		ops []*protocol.OperationObject
-	)
-
-	for {
-		ops, err = a.GetOpsInBlock(blockNum, onlyVirtual)
-		if err == nil {
-			break
-		}
-		fmt.Printf("Retry get ops in block {%+v}: %v\n", blockNum, err)
-	}
-	ch <- &WrapOpsInBlock{
-		BlockNum:   blockNum,
-		Operations: ops,
+// GetOpsInBlockContext is like GetOpsInBlock but aborts the request when ctx is done.
+func (a *API) GetOpsInBlockContext(ctx context.Context, blockNum uint, onlyVirtual bool) (ops []*protocol.OperationObject, err error) {
+	ops, err = CallTyped[[]*protocol.OperationObject](ctx, a, "condenser_api", "get_ops_in_block", []interface{}{blockNum, onlyVirtual})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to GetOpsInBlock")
 	}
+	return ops, nil
 }
 
 // GetOpsInBlocks gets operations in multiple blocks in the range [from, to).
@@ -343,29 +479,36 @@ func (a *API) wrapGetOpsInBlock(blockNum uint, onlyVirtual bool, ch chan<- *Wrap
 // If onlyVirtual is true, returns only virtual operations.
 // Returns a map keyed by block number for easy lookup.
 func (a *API) GetOpsInBlocks(from, to uint, onlyVirtual bool) (opsMap map[uint][]*protocol.OperationObject, err error) {
-	// check params
+	return a.GetOpsInBlocksContext(context.Background(), from, to, onlyVirtual)
+}
+
+// GetOpsInBlocksContext is like GetOpsInBlocks but aborts the outstanding
+// requests and returns ctx.Err() when ctx is done. Requests are bounded and
+// batched like GetBlocksContext; on a *BlockRangeError the map holds the
+// blocks before the failed one. Use EachOpsInBlock for very large ranges.
+func (a *API) GetOpsInBlocksContext(ctx context.Context, from, to uint, onlyVirtual bool) (opsMap map[uint][]*protocol.OperationObject, err error) {
 	if from >= to {
 		return opsMap, errors.Errorf("unexpected params {from: %v}, {to: %v}\n", from, to)
 	}
-	// init
-	ch := make(chan *WrapOpsInBlock, to-from)
 	opsMap = make(map[uint][]*protocol.OperationObject, to-from)
-	// get operations
-	for i := from; i < to; i++ {
-		go a.wrapGetOpsInBlock(i, onlyVirtual, ch)
-	}
-	// get results
-	for i := from; i < to; i++ {
-		result := <-ch
-		opsMap[result.BlockNum] = result.Operations
+	err = a.EachOpsInBlock(ctx, from, to, onlyVirtual, func(b *WrapOpsInBlock) error {
+		opsMap[b.BlockNum] = b.Operations
+		return nil
+	})
+	if err != nil {
+		var rangeErr *BlockRangeError
+		if errors.As(err, &rangeErr) {
+			return opsMap, err
+		}
+		return nil, err
 	}
-	return
+	return opsMap, nil
 }
 
 // ---------------------------------------------------------------------------
 // Conveyor-facing convenience wrappers (G2).
 //
-// Each method below is a typed wrapper over CallWithResult for the
+// Each method below is a typed wrapper over CallTyped for the
 // condenser_api / database_api calls that conveyor relies on heavily
 // (user-search, prices). Result structs are reused from steemutil's
 // protocol/api package; only AccountHistoryEntry (a [index, body] tuple) is
@@ -379,13 +522,19 @@ func (a *API) GetOpsInBlocks(from, to uint, onlyVirtual bool) (opsMap map[uint][
 
 // GetAccounts calls condenser_api.get_accounts.
 // The param is a positional array wrapping the names array: [["n1","n2"]].
+// All names go into one request; use GetAccountsBulk for long lists.
 func (a *API) GetAccounts(names []string) ([]*protocolapi.ExtendedAccount, error) {
-	var result []*protocolapi.ExtendedAccount
-	if err := a.CallWithResult(
+	return a.GetAccountsContext(context.Background(), names)
+}
+
+// GetAccountsContext is like GetAccounts but aborts the request when ctx is done.
+func (a *API) GetAccountsContext(ctx context.Context, names []string) ([]*protocolapi.ExtendedAccount, error) {
+	result, err := CallTyped[[]*protocolapi.ExtendedAccount](
+		ctx, a,
 		"condenser_api", "get_accounts",
 		[]interface{}{names},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetAccounts")
 	}
 	return result, nil
@@ -394,12 +543,17 @@ func (a *API) GetAccounts(names []string) ([]*protocolapi.ExtendedAccount, error
 // GetFollowCount calls condenser_api.get_follow_count.
 // The param is a positional array: [account].
 func (a *API) GetFollowCount(account string) (*protocolapi.FollowCountReturn, error) {
-	var result protocolapi.FollowCountReturn
-	if err := a.CallWithResult(
+	return a.GetFollowCountContext(context.Background(), account)
+}
+
+// GetFollowCountContext is like GetFollowCount but aborts the request when ctx is done.
+func (a *API) GetFollowCountContext(ctx context.Context, account string) (*protocolapi.FollowCountReturn, error) {
+	result, err := CallTyped[protocolapi.FollowCountReturn](
+		ctx, a,
 		"condenser_api", "get_follow_count",
 		[]interface{}{account},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetFollowCount")
 	}
 	return &result, nil
@@ -409,12 +563,17 @@ func (a *API) GetFollowCount(account string) (*protocolapi.FollowCountReturn, er
 // followType is "blog" or "ignore". The param is a positional array:
 // [account, start, followType, limit].
 func (a *API) GetFollowers(account, start, followType string, limit int) ([]*protocolapi.FollowReturn, error) {
-	var result []*protocolapi.FollowReturn
-	if err := a.CallWithResult(
+	return a.GetFollowersContext(context.Background(), account, start, followType, limit)
+}
+
+// GetFollowersContext is like GetFollowers but aborts the request when ctx is done.
+func (a *API) GetFollowersContext(ctx context.Context, account, start, followType string, limit int) ([]*protocolapi.FollowReturn, error) {
+	result, err := CallTyped[[]*protocolapi.FollowReturn](
+		ctx, a,
 		"condenser_api", "get_followers",
 		[]interface{}{account, start, followType, limit},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetFollowers")
 	}
 	return result, nil
@@ -424,12 +583,17 @@ func (a *API) GetFollowers(account, start, followType string, limit int) ([]*pro
 // followType is "blog" or "ignore". The param is a positional array:
 // [account, start, followType, limit].
 func (a *API) GetFollowing(account, start, followType string, limit int) ([]*protocolapi.FollowReturn, error) {
-	var result []*protocolapi.FollowReturn
-	if err := a.CallWithResult(
+	return a.GetFollowingContext(context.Background(), account, start, followType, limit)
+}
+
+// GetFollowingContext is like GetFollowing but aborts the request when ctx is done.
+func (a *API) GetFollowingContext(ctx context.Context, account, start, followType string, limit int) ([]*protocolapi.FollowReturn, error) {
+	result, err := CallTyped[[]*protocolapi.FollowReturn](
+		ctx, a,
 		"condenser_api", "get_following",
 		[]interface{}{account, start, followType, limit},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetFollowing")
 	}
 	return result, nil
@@ -439,17 +603,22 @@ func (a *API) GetFollowing(account, start, followType string, limit int) ([]*pro
 //
 // from/limit follow conveyor's accountHistoryGenerator pagination convention
 // (src/user-search/client.ts): the first page uses from=-1 (newest), limit=1000;
-// subsequent pages step pointer backwards by limit, flooring at limit. This SDK
-// method returns a single page; the paging loop itself is driven by the caller.
+// subsequent pages step pointer backwards by limit, flooring at limit. This
+// method returns a single page; IterateAccountHistory drives the paging loop.
 //
 // The param is a positional array: [account, from, limit].
 func (a *API) GetAccountHistory(account string, from int64, limit int) ([]*AccountHistoryEntry, error) {
-	var result []*AccountHistoryEntry
-	if err := a.CallWithResult(
+	return a.GetAccountHistoryContext(context.Background(), account, from, limit)
+}
+
+// GetAccountHistoryContext is like GetAccountHistory but aborts the request when ctx is done.
+func (a *API) GetAccountHistoryContext(ctx context.Context, account string, from int64, limit int) ([]*AccountHistoryEntry, error) {
+	result, err := CallTyped[[]*AccountHistoryEntry](
+		ctx, a,
 		"condenser_api", "get_account_history",
 		[]interface{}{account, from, limit},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetAccountHistory")
 	}
 	return result, nil
@@ -460,12 +629,17 @@ func (a *API) GetAccountHistory(account string, from int64, limit int) ([]*Accou
 // number of names returned. The param is a positional array:
 // [lowerBound, limit]. Returns the matched account names.
 func (a *API) LookupAccounts(lowerBound string, limit int) ([]string, error) {
-	var result []string
-	if err := a.CallWithResult(
+	return a.LookupAccountsContext(context.Background(), lowerBound, limit)
+}
+
+// LookupAccountsContext is like LookupAccounts but aborts the request when ctx is done.
+func (a *API) LookupAccountsContext(ctx context.Context, lowerBound string, limit int) ([]string, error) {
+	result, err := CallTyped[[]string](
+		ctx, a,
 		"condenser_api", "lookup_accounts",
 		[]interface{}{lowerBound, limit},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to LookupAccounts")
 	}
 	return result, nil
@@ -473,26 +647,29 @@ func (a *API) LookupAccounts(lowerBound string, limit int) ([]string, error) {
 
 // GetOrderBook calls condenser_api.get_order_book.
 //
-// condenser_api is used (rather than database_api) because condenser_api's
-// handlers take positional-array params, which is what this SDK's jsonrpc2
-// layer emits. database_api's get_order_book takes a named object {"limit":N},
-// which the current RpcSendData.Params ([]any) cannot represent.
+// condenser_api is used (rather than database_api) because its result decodes
+// straight into protocolapi.OrderBook. database_api's get_order_book takes a
+// named object {"limit":N} and can be called with CallNamed.
 //
 // The param is a positional array: [limit].
 //
 // Convention note: steemd's json_rpc plugin supports two wire forms — the
-// dotted "api.method" form (used here, the current/preferred path per
+// dotted "api.method" form (used by default, the current/preferred path per
 // json_rpc_plugin.cpp:288) and the legacy "call" form where method=="call"
-// and api/method are packed into params. New code uses the dotted form; the
-// "call" form is retained only for backwards compatibility and is slated for
-// retirement when steemutil's jsonrpc2 is cleaned up.
+// and api/method are packed into params. WithLegacyCallForm switches every
+// call to the "call" form for nodes or proxies that only accept it.
 func (a *API) GetOrderBook(limit int) (*protocolapi.OrderBook, error) {
-	var result protocolapi.OrderBook
-	if err := a.CallWithResult(
+	return a.GetOrderBookContext(context.Background(), limit)
+}
+
+// GetOrderBookContext is like GetOrderBook but aborts the request when ctx is done.
+func (a *API) GetOrderBookContext(ctx context.Context, limit int) (*protocolapi.OrderBook, error) {
+	result, err := CallTyped[protocolapi.OrderBook](
+		ctx, a,
 		"condenser_api", "get_order_book",
 		[]interface{}{limit},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetOrderBook")
 	}
 	return &result, nil
@@ -501,19 +678,23 @@ func (a *API) GetOrderBook(limit int) (*protocolapi.OrderBook, error) {
 // GetFeedHistory calls condenser_api.get_feed_history.
 //
 // condenser_api is used (rather than database_api) for the same reason as
-// GetOrderBook: condenser_api takes positional-array params (here an empty
-// array), matching what this SDK's jsonrpc2 layer emits. database_api's
-// get_feed_history takes a named object, which the current Params ([]any)
-// cannot represent. Takes no params.
+// GetOrderBook: its result decodes straight into protocolapi.FeedHistory.
+// database_api's get_feed_history takes a named object and can be called
+// with CallNamed. Takes no params.
 //
 // See GetOrderBook for the dotted-form-vs-call-form convention note.
 func (a *API) GetFeedHistory() (*protocolapi.FeedHistory, error) {
-	var result protocolapi.FeedHistory
-	if err := a.CallWithResult(
+	return a.GetFeedHistoryContext(context.Background())
+}
+
+// GetFeedHistoryContext is like GetFeedHistory but aborts the request when ctx is done.
+func (a *API) GetFeedHistoryContext(ctx context.Context) (*protocolapi.FeedHistory, error) {
+	result, err := CallTyped[protocolapi.FeedHistory](
+		ctx, a,
 		"condenser_api", "get_feed_history",
 		[]interface{}{},
-		&result,
-	); err != nil {
+	)
+	if err != nil {
 		return nil, errors.Wrap(err, "failed to GetFeedHistory")
 	}
 	return &result, nil
@@ -0,0 +1,344 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBatchSize is the number of requests GetBlocks and GetOpsInBlocks put
+// in one batch when batching is enabled with a size of 0 or less. It matches
+// the batch limit of the public Steem API gateway.
+const DefaultBatchSize = 50
+
+// Batch collects JSON-RPC calls and sends them as a single JSON-RPC 2.0 batch
+// request, then decodes each response into the result given to Add.
+//
+//	var block1, block2 protocolapi.Block
+//	err := a.NewBatch().
+//		Add("condenser_api", "get_block", []interface{}{1}, &block1).
+//		Add("condenser_api", "get_block", []interface{}{2}, &block2).
+//		Do()
+//
+// Each item succeeds or fails on its own: Do returns a *BatchError listing the
+// failed items, and Err reports the error of a single item. Every item passes
+// through the API's interceptors like a single call; the items that reach the
+// end of the chain are sent together. The whole batch is retried according to
+// the API's retry policy when the request itself fails.
+//
+// A Batch is not safe for concurrent use and should be sent only once.
+type Batch struct {
+	api   *API
+	items []*batchItem
+}
+
+type batchItem struct {
+	req    *RPCRequest
+	method string
+	result interface{}
+	resp   *RPCResponse
+	err    error
+}
+
+// BatchError is returned by Batch.Do when one or more items failed. Errors is
+// indexed like the items, with nil for items that succeeded.
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	var failed []string
+	for i, err := range e.Errors {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("item %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d batch item(s) failed: %s", len(failed), len(e.Errors), strings.Join(failed, "; "))
+}
+
+// NewBatch starts an empty batch sent through this API's transport.
+func (a *API) NewBatch() *Batch {
+	return &Batch{api: a}
+}
+
+// Add queues apiName.method with positional params. On success the result is
+// decoded into result, which may be nil to discard it.
+func (b *Batch) Add(apiName, method string, params []interface{}, result interface{}) *Batch {
+	if params == nil {
+		params = []interface{}{}
+	}
+	return b.add(apiName, method, params, result)
+}
+
+// AddNamed queues apiName.method with a named-object params body, like
+// API.CallNamed; nil params sends {}.
+func (b *Batch) AddNamed(apiName, method string, params interface{}, result interface{}) *Batch {
+	if params == nil {
+		params = struct{}{}
+	}
+	return b.add(apiName, method, params, result)
+}
+
+func (b *Batch) add(apiName, method string, params interface{}, result interface{}) *Batch {
+	b.items = append(b.items, &batchItem{
+		req:    b.api.newRequest(b.api.wireCall(apiName, method, params)),
+		method: fmt.Sprintf("%s.%s", apiName, method),
+		result: result,
+	})
+	return b
+}
+
+// Len returns the number of queued calls.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Err returns the error of item i after Do, or nil if it succeeded.
+func (b *Batch) Err(i int) error {
+	return b.items[i].err
+}
+
+// Do sends the batch. See DoContext.
+func (b *Batch) Do() error {
+	return b.DoContext(context.Background())
+}
+
+// DoContext sends the batch and decodes every response. It returns an error
+// without touching the results if the request as a whole failed, and a
+// *BatchError if only some items failed.
+func (b *Batch) DoContext(ctx context.Context) error {
+	if len(b.items) == 0 {
+		return nil
+	}
+	start := time.Now()
+	if err := b.send(ctx); err != nil {
+		err = errors.Wrap(err, "failed to send RPC batch")
+		b.observe(time.Since(start), err)
+		return err
+	}
+
+	failed := false
+	for _, item := range b.items {
+		if item.err == nil {
+			item.err = item.decode(item.resp)
+		}
+		failed = failed || item.err != nil
+	}
+	b.observe(time.Since(start), nil)
+	if !failed {
+		return nil
+	}
+	batchErr := &BatchError{Errors: make([]error, len(b.items))}
+	for i, item := range b.items {
+		batchErr.Errors[i] = item.err
+	}
+	return batchErr
+}
+
+// observe reports every item to the API's metrics as a call of its method
+// that took d, failed with batchErr if the batch as a whole failed, or with
+// its own error otherwise.
+func (b *Batch) observe(d time.Duration, batchErr error) {
+	for _, item := range b.items {
+		err := batchErr
+		if err == nil {
+			err = item.err
+		}
+		b.api.metrics.ObserveCall(item.method, d, ErrorClass(err))
+	}
+}
+
+// send runs every item through the interceptor chain and sets its response
+// or error. It returns an error only if the batch request itself failed.
+func (b *Batch) send(ctx context.Context) error {
+	s := &batchSender{
+		api:     b.api,
+		ctx:     ctx,
+		pending: len(b.items),
+		reqs:    make([]*RPCRequest, len(b.items)),
+		ctxs:    make([]context.Context, len(b.items)),
+		done:    make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	for i, item := range b.items {
+		wg.Add(1)
+		go func(i int, item *batchItem) {
+			defer wg.Done()
+			joined := false
+			resp, err := b.api.intercept(ctx, item.req, func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+				s.mu.Lock()
+				first := !joined
+				joined = true
+				s.mu.Unlock()
+				if !first {
+					// An interceptor calling next again, e.g. to retry on
+					// its own, cannot rejoin a batch already sent.
+					return s.sendAlone(ctx, req)
+				}
+				return s.join(ctx, i, req)
+			})
+			s.mu.Lock()
+			if !joined {
+				// Answered by an interceptor without reaching the node.
+				joined = true
+				s.leaveLocked()
+			}
+			s.mu.Unlock()
+			if err != nil {
+				item.err = errors.Wrapf(err, "failed to send RPC request for %s", item.method)
+			}
+			item.resp = resp
+		}(i, item)
+	}
+	wg.Wait()
+	return s.err
+}
+
+// batchSender collects the requests of a Batch as they leave the interceptor
+// chain, and sends them as one batch once every item has either arrived or
+// been answered by an interceptor.
+type batchSender struct {
+	api *API
+	ctx context.Context
+
+	mu      sync.Mutex
+	pending int               // items neither arrived nor answered
+	reqs    []*RPCRequest     // by item, nil for items that did not arrive
+	ctxs    []context.Context // by item, like reqs
+	sent    bool
+
+	done  chan struct{} // closed once the batch is answered
+	resps map[uint64]*RPCResponse
+	err   error
+}
+
+// join adds req as item i of the batch and waits for its response.
+func (s *batchSender) join(ctx context.Context, i int, req *RPCRequest) (*RPCResponse, error) {
+	s.mu.Lock()
+	s.reqs[i] = req
+	s.ctxs[i] = ctx
+	s.leaveLocked()
+	s.mu.Unlock()
+
+	<-s.done
+	if s.err != nil {
+		return nil, s.err
+	}
+	resp, ok := s.resps[req.ID]
+	if !ok {
+		return nil, errors.Errorf("no response for %s in batch", callName(req.Method, req.Params))
+	}
+	return resp, nil
+}
+
+// leaveLocked counts an item out of the pending ones and sends the batch in
+// the background once none is left. s.mu must be held.
+func (s *batchSender) leaveLocked() {
+	s.pending--
+	if s.pending > 0 || s.sent {
+		return
+	}
+	s.sent = true
+	go s.flush()
+}
+
+func (s *batchSender) flush() {
+	defer close(s.done)
+	// The batch keeps the order the items were added in.
+	var reqs []*RPCRequest
+	var ctxs []context.Context
+	for i, req := range s.reqs {
+		if req != nil {
+			reqs = append(reqs, req)
+			ctxs = append(ctxs, s.ctxs[i])
+		}
+	}
+	if len(reqs) == 0 {
+		return
+	}
+	// The interceptors may have set headers on their contexts, e.g. for
+	// tracing or authentication; the batch request carries all of them.
+	ctx := mergeContextHeaders(s.ctx, ctxs...)
+	var resps []*RPCResponse
+	// A batch holding a broadcast is resent only under the broadcast rule.
+	_, s.err = s.api.withRetry(ctx, "batch", hasBroadcast(reqs), func() (*RPCResponse, error) {
+		var err error
+		resps, err = sendBatch(ctx, s.api.transport, reqs)
+		return nil, err
+	})
+	s.resps = make(map[uint64]*RPCResponse, len(resps))
+	for _, resp := range resps {
+		if resp != nil {
+			s.resps[resp.ID] = resp
+		}
+	}
+}
+
+// sendAlone sends req on its own, outside the batch.
+func (s *batchSender) sendAlone(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	method := callName(req.Method, req.Params)
+	return s.api.withRetry(ctx, method, isBroadcastMethod(method), func() (*RPCResponse, error) {
+		return s.api.transport.Send(ctx, req)
+	})
+}
+
+func (item *batchItem) decode(resp *RPCResponse) error {
+	method := item.method
+	if resp == nil {
+		return errors.Errorf("no response for %s in batch", method)
+	}
+	if resp.HasError() {
+		return newRPCError(method, resp.Error)
+	}
+	if item.result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, item.result); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal RPC result for %s", method)
+	}
+	return nil
+}
+
+// sendBatch sends reqs through t as one batch if t supports it, or as
+// concurrent single requests otherwise.
+func sendBatch(ctx context.Context, t Transport, reqs []*RPCRequest) ([]*RPCResponse, error) {
+	if bt, ok := t.(BatchTransport); ok {
+		return bt.SendBatch(ctx, reqs)
+	}
+	resps := make([]*RPCResponse, len(reqs))
+	errs := make([]error, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req *RPCRequest) {
+			defer wg.Done()
+			resps[i], errs[i] = t.Send(ctx, req)
+		}(i, req)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resps, nil
+}
+
+// blockBatchError names the first failed block of a per-block batch, or
+// returns err as is when the whole batch failed.
+func blockBatchError(batch *Batch, err error, start uint) error {
+	if _, ok := err.(*BatchError); !ok {
+		return err
+	}
+	for i := 0; i < batch.Len(); i++ {
+		if itemErr := batch.Err(i); itemErr != nil {
+			return &BlockRangeError{Block: start + uint(i), Err: itemErr}
+		}
+	}
+	return err
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/steemtest"
+	protocolapi "github.com/steemit/steemutil/protocol/api"
+)
+
+// dropLast passes batches to a node but loses the response to the last
+// request of each, as a faulty proxy might.
+type dropLast struct {
+	*HTTPTransport
+}
+
+func (d dropLast) SendBatch(ctx context.Context, reqs []*RPCRequest) ([]*RPCResponse, error) {
+	resps, err := d.HTTPTransport.SendBatch(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	kept := resps[:0]
+	for _, resp := range resps {
+		if resp.ID != reqs[len(reqs)-1].ID {
+			kept = append(kept, resp)
+		}
+	}
+	return kept, nil
+}
+
+func firstParam(req *RPCRequest) float64 {
+	raw, _ := json.Marshal(req.Params)
+	var params []interface{}
+	json.Unmarshal(raw, &params)
+	n, _ := params[0].(float64)
+	return n
+}
+
+func TestBatch_DemultiplexesByID(t *testing.T) {
+	node := steemtest.NewNode(steemtest.WithReversedBatches())
+	defer node.Close()
+	node.Follow("bob", "alice")
+	node.Follow("carol", "alice")
+	node.Follow("alice", "bob")
+	api := NewAPI(node.URL)
+
+	var a, b protocolapi.FollowCountReturn
+	err := api.NewBatch().
+		Add("condenser_api", "get_follow_count", []interface{}{"alice"}, &a).
+		Add("condenser_api", "get_follow_count", []interface{}{"bob"}, &b).
+		Do()
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if a.Account != "alice" || a.FollowerCount != 2 {
+		t.Errorf("unexpected first result: %+v", a)
+	}
+	if b.Account != "bob" || b.FollowerCount != 1 {
+		t.Errorf("unexpected second result: %+v", b)
+	}
+	if got := node.Posts(); got != 1 {
+		t.Errorf("expected one HTTP request, got %d", got)
+	}
+}
+
+func TestBatch_PerItemErrors(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddAccount("alice", nil)
+	api := NewAPI(node.URL, WithTransport(dropLast{NewHTTPTransport(node.URL, nil)}))
+
+	results := make([][]string, 3)
+	batch := api.NewBatch()
+	for i, limit := range []int{1, 1001, 1} {
+		batch.Add("condenser_api", "lookup_accounts", []interface{}{"a", limit}, &results[i])
+	}
+	err := batch.Do()
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 3 {
+		t.Fatalf("expected BatchError, got %v", err)
+	}
+	if batch.Err(0) != nil || len(results[0]) != 1 {
+		t.Errorf("item 0 should succeed, got %v, %v", batch.Err(0), results[0])
+	}
+	if e := batch.Err(1); e == nil || !strings.Contains(e.Error(), "limit <= 1000") {
+		t.Errorf("item 1 should carry the RPC error, got %v", e)
+	}
+	if e := batch.Err(2); e == nil || !strings.Contains(e.Error(), "no response") {
+		t.Errorf("item 2 should report a missing response, got %v", e)
+	}
+}
+
+func TestBatch_RejectedBatch(t *testing.T) {
+	node := steemtest.NewNode(steemtest.WithMaxBatchSize(1))
+	defer node.Close()
+	api := NewAPI(node.URL, WithMaxRetry(0))
+
+	err := api.NewBatch().
+		Add("condenser_api", "get_config", nil, nil).
+		Add("condenser_api", "get_config", nil, nil).
+		Do()
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("expected batch rejection, got %v", err)
+	}
+}
+
+func TestBatch_FallbackForSingleRequestTransport(t *testing.T) {
+	var calls int32
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(fmt.Sprintf(`%d`, req.ID))}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport))
+
+	ids := make([]uint64, 4)
+	batch := api.NewBatch()
+	for i := range ids {
+		batch.Add("condenser_api", "get_config", nil, &ids[i])
+	}
+	if err := batch.Do(); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("expected one Send per item, got %d", calls)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] != ids[i-1]+1 {
+			t.Errorf("results out of order: %v", ids)
+		}
+	}
+}
+
+func TestGetBlocks_Batched(t *testing.T) {
+	node := steemtest.NewNode(steemtest.WithReversedBatches())
+	defer node.Close()
+	node.AdvanceBlocks(20)
+	api := NewAPI(node.URL, WithBatchSize(2))
+
+	blocks, err := api.GetBlocks(10, 15)
+	if err != nil {
+		t.Fatalf("GetBlocks failed: %v", err)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("expected 5 blocks, got %d", len(blocks))
+	}
+	for i, b := range blocks {
+		want, _ := node.Block(uint32(10 + i))
+		if b.BlockNum != uint(want.Num) || b.Block.BlockId != want.ID {
+			t.Errorf("block %d: got num %d id %s", want.Num, b.BlockNum, b.Block.BlockId)
+		}
+	}
+	if got := node.Posts(); got != 3 {
+		t.Errorf("expected 3 batches of at most 2, got %d requests", got)
+	}
+}
+
+func TestGetBlocks_BatchedItemError(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AdvanceBlocks(20)
+	node.SetCallError("condenser_api.get_block", []interface{}{12}, -32000, "boom")
+	api := NewAPI(node.URL, WithBatchSize(10))
+
+	_, err := api.GetBlocks(10, 15)
+	if err == nil || !strings.Contains(err.Error(), "get block {12} error") {
+		t.Errorf("expected block 12 to fail, got %v", err)
+	}
+}
+
+func TestGetOpsInBlocks_Batched(t *testing.T) {
+	node := steemtest.NewNode(steemtest.WithReversedBatches())
+	defer node.Close()
+	node.AdvanceBlocks(98)
+	for i := 0; i < 3; i++ {
+		node.PushVirtualOp(steemtest.Op{Type: "producer_reward", Value: map[string]interface{}{"producer": "initminer", "vesting_shares": "1.000000 VESTS"}})
+		node.AdvanceBlocks(1)
+	}
+	api := NewAPI(node.URL, WithBatchSize(0))
+
+	opsMap, err := api.GetOpsInBlocks(100, 103, true)
+	if err != nil {
+		t.Fatalf("GetOpsInBlocks failed: %v", err)
+	}
+	for n := uint(100); n < 103; n++ {
+		if ops := opsMap[n]; len(ops) != 1 || uint(ops[0].BlockNumber) != n {
+			t.Errorf("block %d: unexpected ops %+v", n, ops)
+		}
+	}
+	if got := node.Posts(); got != 1 {
+		t.Errorf("expected a single batch, got %d requests", got)
+	}
+}
+
+func TestPool_SendBatchFailover(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	pool, err := NewPool([]string{"http://127.0.0.1:1", node.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPI(node.URL, WithTransport(pool))
+
+	var a, b protocolapi.DynamicGlobalProperties
+	if err := api.NewBatch().
+		Add("condenser_api", "get_dynamic_global_properties", nil, &a).
+		Add("condenser_api", "get_dynamic_global_properties", nil, &b).
+		Do(); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if a.HeadBlockNumber != 1 || b.HeadBlockNumber != 1 {
+		t.Errorf("unexpected results %+v %+v", a, b)
+	}
+}
+
+// TestBatch_BroadcastNotResent checks a batch holding a broadcast is only
+// resent when the broadcast provably never reached the node.
+func TestBatch_BroadcastNotResent(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		broadcasts int32
+	}{
+		{"read", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}, 1},
+		{"dial", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var broadcasts int32
+			transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+				if req.Method == "condenser_api.broadcast_transaction" && atomic.AddInt32(&broadcasts, 1) == 1 {
+					return nil, tc.err
+				}
+				return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+			})
+			api := NewAPI("http://node", WithTransport(transport), fastRetry(3))
+
+			api.NewBatch().
+				Add("condenser_api", "get_config", nil, nil).
+				Add("condenser_api", "broadcast_transaction", []interface{}{map[string]interface{}{}}, nil).
+				Do()
+			if got := atomic.LoadInt32(&broadcasts); got != tc.broadcasts {
+				t.Errorf("expected the broadcast to be sent %d time(s), got %d", tc.broadcasts, got)
+			}
+		})
+	}
+}
+
+func TestPool_SendBatchBroadcastNoFailover(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": errNode(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}),
+		"http://b": okNode(`{}`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+
+	_, err := pool.SendBatch(context.Background(), []*RPCRequest{
+		{ID: 1, Method: "condenser_api.get_config"},
+		{ID: 2, Method: "condenser_api.broadcast_transaction"},
+	})
+	if err == nil {
+		t.Fatal("expected the node a error")
+	}
+	if nodes.count("http://b") != 0 {
+		t.Error("a batch holding a broadcast must not fail over after an ambiguous error")
+	}
+}
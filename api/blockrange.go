@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	protocolapi "github.com/steemit/steemutil/protocol/api"
+)
+
+// DefaultConcurrency is the number of requests GetBlocks and GetOpsInBlocks
+// keep in flight at once unless WithConcurrency says otherwise.
+const DefaultConcurrency = 16
+
+// BlockRangeError reports a block of a GetBlocks, GetOpsInBlocks, EachBlock
+// or EachOpsInBlock range that could not be fetched after the retry policy
+// was exhausted. Results for the blocks before Next were delivered; the rest
+// of the range was abandoned.
+type BlockRangeError struct {
+	// Block is the block that failed.
+	Block uint
+	// Next is the first block whose result was not delivered. Fetch
+	// [Next, to) again to complete the range.
+	Next uint
+	// Err is the error of the last attempt.
+	Err error
+
+	what string // "get block" or "get ops in block"
+}
+
+func (e *BlockRangeError) Error() string {
+	return fmt.Sprintf("%s {%v} error: %v", e.what, e.Block, e.Err)
+}
+
+// Unwrap returns Err, so errors.Is and errors.As see the RPC error.
+func (e *BlockRangeError) Unwrap() error {
+	return e.Err
+}
+
+// EachBlock fetches the blocks in [from, to) like GetBlocksContext but hands
+// them to fn in block order as they arrive instead of collecting them, so
+// memory stays flat however large the range is: at most twice WithConcurrency
+// requests (or batches) are fetched ahead of fn.
+//
+// If fn returns an error, the outstanding requests are cancelled and
+// EachBlock returns that error.
+func (a *API) EachBlock(ctx context.Context, from, to uint, fn func(*WrapBlock) error) error {
+	if from >= to {
+		return errors.Errorf("unexpected params {from: %v}, {to: %v}\n", from, to)
+	}
+	step, fetch := uint(1), a.fetchBlock
+	if a.batchSize > 0 {
+		step, fetch = uint(a.batchSize), a.fetchBlocksBatched
+	}
+	return fetchRange(ctx, from, to, step, a.concurrency, "get block", fetch, func(blocks []*WrapBlock) error {
+		for _, b := range blocks {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EachOpsInBlock is EachBlock for GetOpsInBlocksContext: it hands the
+// operations of every block in [from, to) to fn in block order.
+func (a *API) EachOpsInBlock(ctx context.Context, from, to uint, onlyVirtual bool, fn func(*WrapOpsInBlock) error) error {
+	if from >= to {
+		return errors.Errorf("unexpected params {from: %v}, {to: %v}\n", from, to)
+	}
+	step := uint(1)
+	fetch := func(ctx context.Context, n, _ uint) ([]*WrapOpsInBlock, error) {
+		ops, err := a.GetOpsInBlockContext(ctx, n, onlyVirtual)
+		return []*WrapOpsInBlock{{BlockNum: n, Operations: ops}}, err
+	}
+	if a.batchSize > 0 {
+		step = uint(a.batchSize)
+		fetch = func(ctx context.Context, start, end uint) ([]*WrapOpsInBlock, error) {
+			return a.fetchOpsInBlocksBatched(ctx, start, end, onlyVirtual)
+		}
+	}
+	return fetchRange(ctx, from, to, step, a.concurrency, "get ops in block", fetch, func(blocks []*WrapOpsInBlock) error {
+		for _, b := range blocks {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (a *API) fetchBlock(ctx context.Context, n, _ uint) ([]*WrapBlock, error) {
+	block, err := a.GetBlockContext(ctx, n)
+	return []*WrapBlock{{BlockNum: n, Block: block}}, err
+}
+
+// fetchBlocksBatched fetches the blocks in [start, end) in one batch.
+func (a *API) fetchBlocksBatched(ctx context.Context, start, end uint) ([]*WrapBlock, error) {
+	blocks := make([]*WrapBlock, 0, end-start)
+	batch := a.NewBatch()
+	for n := start; n < end; n++ {
+		b := &WrapBlock{BlockNum: n, Block: &protocolapi.Block{}}
+		blocks = append(blocks, b)
+		batch.Add("condenser_api", "get_block", []interface{}{n}, b.Block)
+	}
+	if err := batch.DoContext(ctx); err != nil {
+		return nil, blockBatchError(batch, err, start)
+	}
+	return blocks, nil
+}
+
+// fetchOpsInBlocksBatched fetches the operations of [start, end) in one
+// batch.
+func (a *API) fetchOpsInBlocksBatched(ctx context.Context, start, end uint, onlyVirtual bool) ([]*WrapOpsInBlock, error) {
+	blocks := make([]*WrapOpsInBlock, 0, end-start)
+	batch := a.NewBatch()
+	for n := start; n < end; n++ {
+		b := &WrapOpsInBlock{BlockNum: n}
+		blocks = append(blocks, b)
+		batch.Add("condenser_api", "get_ops_in_block", []interface{}{n, onlyVirtual}, &b.Operations)
+	}
+	if err := batch.DoContext(ctx); err != nil {
+		return nil, blockBatchError(batch, err, start)
+	}
+	return blocks, nil
+}
+
+// fetchRange fetches [from, to) in units of step blocks with a pool of
+// workers and hands every unit to emit in order. Units finished ahead of
+// emit are buffered, and no unit more than 2*workers units ahead of emit is
+// started, which bounds memory regardless of the range size.
+//
+// The first failure cancels the outstanding units. It is reported as a
+// *BlockRangeError naming the failed block (the unit's first block unless
+// fetch returned a *BlockRangeError itself) and the first block not
+// emitted. A done ctx is reported as ctx.Err().
+func fetchRange[T any](ctx context.Context, from, to, step uint, workers int, what string,
+	fetch func(ctx context.Context, start, end uint) (T, error), emit func(T) error) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+
+	type result struct {
+		start uint
+		value T
+		err   error
+	}
+	window := uint(2 * workers)
+	jobs := make(chan uint)
+	// Never more than window units are started but not yet received, so
+	// workers never block on results.
+	results := make(chan result, window)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range jobs {
+				end := start + step
+				if end > to {
+					end = to
+				}
+				value, err := fetch(ctx, start, end)
+				results <- result{start, value, err}
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		close(jobs)
+		wg.Wait()
+	}()
+
+	pending := make(map[uint]result, window)
+	next, emitted := from, from
+	for emitted < to {
+		var send chan<- uint
+		if next < to && next-emitted < window*step {
+			send = jobs
+		}
+		select {
+		case send <- next:
+			next += step
+		case r := <-results:
+			if r.err != nil {
+				if parent.Err() != nil {
+					return parent.Err()
+				}
+				var rangeErr *BlockRangeError
+				if !errors.As(r.err, &rangeErr) {
+					rangeErr = &BlockRangeError{Block: r.start, Err: r.err}
+				}
+				rangeErr.Next = emitted
+				rangeErr.what = what
+				return rangeErr
+			}
+			pending[r.start] = r
+			for {
+				r, ok := pending[emitted]
+				if !ok {
+					break
+				}
+				delete(pending, emitted)
+				if err := emit(r.value); err != nil {
+					return err
+				}
+				emitted += step
+				if emitted > to {
+					emitted = to
+				}
+			}
+		case <-parent.Done():
+			return parent.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+func TestGetBlocks_ConcurrencyCap(t *testing.T) {
+	chain := newStandInChain(t, 1000, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()), WithConcurrency(4))
+
+	blocks, err := api.GetBlocks(1, 201)
+	if err != nil {
+		t.Fatalf("GetBlocks failed: %v", err)
+	}
+	if len(blocks) != 200 {
+		t.Fatalf("expected 200 blocks, got %d", len(blocks))
+	}
+	assertInOrder(t, chain, blocks, 1)
+	if max := atomic.LoadInt32(&chain.maxInFl); max > 4 {
+		t.Errorf("expected at most 4 concurrent requests, got %d", max)
+	}
+}
+
+func TestGetBlocks_PartialResult(t *testing.T) {
+	chain := newStandInChain(t, 100, 0)
+	chain.failAt = 15
+	api := NewAPI("http://node", WithTransport(chain.transport()), WithConcurrency(2), fastRetry(1))
+
+	blocks, err := api.GetBlocks(10, 40)
+	var rangeErr *BlockRangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("expected a *BlockRangeError, got %v", err)
+	}
+	if rangeErr.Block != 15 || rangeErr.Next != 15 {
+		t.Errorf("expected block 15 to fail with results up to 15, got %+v", rangeErr)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("expected the 5 blocks before the failure, got %d", len(blocks))
+	}
+	assertInOrder(t, chain, blocks, 10)
+}
+
+func TestGetOpsInBlocks_BatchedPartialResult(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AdvanceBlocks(40)
+	node.SetCallError("condenser_api.get_ops_in_block", []interface{}{23, false}, -32000, "boom")
+	api := NewAPI(node.URL, WithBatchSize(5), WithConcurrency(1), fastRetry(0))
+
+	opsMap, err := api.GetOpsInBlocks(10, 30, false)
+	var rangeErr *BlockRangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("expected a *BlockRangeError, got %v", err)
+	}
+	if rangeErr.Block != 23 || rangeErr.Next != 20 {
+		t.Errorf("expected block 23 to fail with results up to 20, got %+v", rangeErr)
+	}
+	if len(opsMap) != 10 {
+		t.Errorf("expected the 10 blocks of the first two batches, got %d", len(opsMap))
+	}
+}
+
+func TestEachBlock_BoundedLookahead(t *testing.T) {
+	chain := newStandInChain(t, 1000, 0)
+	var requests int32
+	transport := chain.transport()
+	counting := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		atomic.AddInt32(&requests, 1)
+		return transport.Send(ctx, req)
+	})
+	api := NewAPI("http://node", WithTransport(counting), WithConcurrency(3))
+
+	next := uint(1)
+	err := api.EachBlock(context.Background(), 1, 301, func(b *WrapBlock) error {
+		if b.BlockNum != next {
+			t.Fatalf("got block %d, want %d", b.BlockNum, next)
+		}
+		next++
+		// Requests started so far: the blocks delivered plus the lookahead.
+		if started := uint(atomic.LoadInt32(&requests)); started > b.BlockNum+6 {
+			t.Fatalf("%d requests started by the time block %d was delivered", started, b.BlockNum)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachBlock failed: %v", err)
+	}
+	if next != 301 {
+		t.Errorf("expected 300 blocks, got %d", next-1)
+	}
+}
+
+func TestEachBlock_StopsOnCallbackError(t *testing.T) {
+	chain := newStandInChain(t, 1000, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	stop := errors.New("stop")
+
+	var seen int
+	err := api.EachBlock(context.Background(), 1, 1000, func(b *WrapBlock) error {
+		seen++
+		if b.BlockNum == 20 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	if seen != 20 {
+		t.Errorf("expected 20 blocks before stopping, got %d", seen)
+	}
+	if n := atomic.LoadInt32(&chain.inFlight); n != 0 {
+		t.Errorf("expected no requests left running, got %d", n)
+	}
+}
@@ -0,0 +1,420 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCacheSize is the number of responses a Cache keeps by default.
+const DefaultCacheSize = 1000
+
+// DefaultCacheTTLs are the methods a Cache caches unless configured
+// otherwise, and for how long. Methods missing here are passed through.
+func DefaultCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		"condenser_api.get_dynamic_global_properties":    time.Second,
+		"database_api.get_dynamic_global_properties":     time.Second,
+		"condenser_api.get_feed_history":                 30 * time.Second,
+		"condenser_api.get_current_median_history_price": 30 * time.Second,
+		"condenser_api.get_accounts":                     3 * time.Second,
+		"condenser_api.get_follow_count":                 10 * time.Second,
+		"condenser_api.get_config":                       time.Hour,
+	}
+}
+
+// blockMethods return data of the block named by their first parameter (or
+// block_num field). Once that block is irreversible the data never changes.
+var blockMethods = map[string]bool{
+	"condenser_api.get_block":        true,
+	"condenser_api.get_block_header": true,
+	"condenser_api.get_ops_in_block": true,
+	"block_api.get_block":            true,
+	"block_api.get_block_header":     true,
+}
+
+// Cache is a Transport that caches node responses in front of another
+// Transport.
+//
+// Responses of methods with a TTL (see DefaultCacheTTLs and WithCacheTTL) are
+// kept for that long. Responses of block methods such as get_block are kept
+// with no expiry once their block is irreversible; blocks that may still be
+// reverted are never cached. The cache learns the last irreversible block from
+// the get_dynamic_global_properties responses passing through it, or from
+// SetIrreversible. At most the configured number of responses are kept, the
+// least recently used being evicted first.
+//
+// Concurrent identical requests for cacheable methods share one in-flight
+// node request. Error responses are not cached, and requests for other
+// methods, including broadcasts, pass straight through.
+//
+// Install a Cache with WithCache, or with WithTransport(NewCache(...)).
+type Cache struct {
+	next Transport
+	ttls map[string]time.Duration
+	size int
+
+	mu           sync.Mutex
+	entries      map[string]*list.Element // of *cacheEntry
+	lru          *list.List               // most recently used first
+	inflight     map[string]*cacheCall
+	irreversible uint32
+	stats        CacheStats
+}
+
+type cacheEntry struct {
+	key     string
+	result  json.RawMessage
+	expires time.Time // zero for no expiry
+	block   uint32    // block the entry belongs to; 0 for none
+}
+
+// cacheCall is a node request other identical requests wait for.
+type cacheCall struct {
+	done chan struct{}
+	resp *RPCResponse
+	err  error
+}
+
+// CacheStats counts how requests were served.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+	Entries   int    `json:"entries"`
+}
+
+// CacheOption configures a Cache.
+type CacheOption func(*Cache)
+
+// WithCacheTTL caches responses of method, e.g.
+// "condenser_api.get_accounts", for ttl. A ttl <= 0 stops caching method.
+func WithCacheTTL(method string, ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		if ttl <= 0 {
+			delete(c.ttls, method)
+			return
+		}
+		c.ttls[method] = ttl
+	}
+}
+
+// WithCacheSize keeps at most n responses (DefaultCacheSize if n <= 0).
+func WithCacheSize(n int) CacheOption {
+	return func(c *Cache) {
+		if n <= 0 {
+			n = DefaultCacheSize
+		}
+		c.size = n
+	}
+}
+
+// NewCache creates a Cache in front of next.
+func NewCache(next Transport, opts ...CacheOption) *Cache {
+	c := &Cache{
+		next:     next,
+		ttls:     DefaultCacheTTLs(),
+		size:     DefaultCacheSize,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		inflight: make(map[string]*cacheCall),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Send serves req from the cache, from an identical request in flight, or
+// from the next transport.
+func (c *Cache) Send(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	key, method, params, ok := c.cacheable(req)
+	if !ok {
+		return c.next.Send(ctx, req)
+	}
+	for {
+		c.mu.Lock()
+		if result, ok := c.getLocked(key); ok {
+			c.stats.Hits++
+			c.mu.Unlock()
+			return cachedResponse(req, result), nil
+		}
+		if call, ok := c.inflight[key]; ok {
+			c.stats.Coalesced++
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if call.err != nil && ctx.Err() == nil &&
+				(errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded)) {
+				continue // the caller that sent it gave up; send it again
+			}
+			if call.err != nil {
+				return nil, call.err
+			}
+			return withID(call.resp, req.ID), nil
+		}
+		call := &cacheCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		c.stats.Misses++
+		c.mu.Unlock()
+
+		call.resp, call.err = c.next.Send(ctx, req)
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if call.err == nil {
+			c.storeLocked(key, method, params, call.resp)
+		}
+		c.mu.Unlock()
+		close(call.done)
+		return call.resp, call.err
+	}
+}
+
+// SendBatch serves what it can from the cache and sends the rest to the next
+// transport as one batch.
+func (c *Cache) SendBatch(ctx context.Context, reqs []*RPCRequest) ([]*RPCResponse, error) {
+	resps := make([]*RPCResponse, len(reqs))
+	type miss struct {
+		i                   int
+		key, method, params string
+	}
+	var misses []miss
+	var forward []*RPCRequest
+	c.mu.Lock()
+	for i, req := range reqs {
+		key, method, params, ok := c.cacheable(req)
+		if ok {
+			if result, ok := c.getLocked(key); ok {
+				c.stats.Hits++
+				resps[i] = cachedResponse(req, result)
+				continue
+			}
+			c.stats.Misses++
+		}
+		misses = append(misses, miss{i, key, method, params})
+		forward = append(forward, req)
+	}
+	c.mu.Unlock()
+	if len(forward) == 0 {
+		return resps, nil
+	}
+
+	got, err := sendBatch(ctx, c.next, forward)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint64]*RPCResponse, len(got))
+	for _, resp := range got {
+		byID[resp.ID] = resp
+	}
+	c.mu.Lock()
+	for _, m := range misses {
+		resp := byID[reqs[m.i].ID]
+		resps[m.i] = resp
+		if resp != nil && m.key != "" {
+			c.storeLocked(m.key, m.method, m.params, resp)
+		}
+	}
+	c.mu.Unlock()
+	// Requests the node did not answer are left out, as a BatchTransport
+	// would; the caller reports them.
+	answered := resps[:0]
+	for _, resp := range resps {
+		if resp != nil {
+			answered = append(answered, resp)
+		}
+	}
+	return answered, nil
+}
+
+// SetIrreversible tells the cache that blocks up to num are irreversible, so
+// block method responses for them may be kept for good. It never moves the
+// irreversible block back.
+func (c *Cache) SetIrreversible(num uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if num > c.irreversible {
+		c.irreversible = num
+	}
+}
+
+// InvalidateBlocks drops the cached responses for block from and later, and
+// treats them as reversible again, e.g. after the cache was told about
+// irreversibility too early.
+func (c *Cache) InvalidateBlocks(from uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if from > 0 && c.irreversible >= from {
+		c.irreversible = from - 1
+	}
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if entry := e.Value.(*cacheEntry); entry.block >= from && entry.block > 0 {
+			c.removeLocked(e)
+		}
+		e = next
+	}
+}
+
+// Invalidate drops every cached response of method.
+func (c *Cache) Invalidate(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := method + "\x00"
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if strings.HasPrefix(e.Value.(*cacheEntry).key, prefix) {
+			c.removeLocked(e)
+		}
+		e = next
+	}
+}
+
+// Purge drops every cached response.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// Close closes the next transport if it holds resources.
+func (c *Cache) Close() error {
+	if closer, ok := c.next.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// cacheable returns the cache key of req, its dotted method name and its
+// encoded params, or false if responses to req are not cached.
+func (c *Cache) cacheable(req *RPCRequest) (key, method, params string, ok bool) {
+	method, raw, ok := unwrapCall(req)
+	if !ok {
+		return "", "", "", false
+	}
+	if _, ok := c.ttls[method]; !ok && !blockMethods[method] {
+		return "", "", "", false
+	}
+	return method + "\x00" + string(raw), method, string(raw), true
+}
+
+// unwrapCall returns the dotted method name and encoded params of req, also
+// for requests in the legacy "call" form.
+func unwrapCall(req *RPCRequest) (string, json.RawMessage, bool) {
+	raw, err := json.Marshal(req.Params)
+	if err != nil {
+		return "", nil, false
+	}
+	if req.Method != "call" {
+		return req.Method, raw, true
+	}
+	var call []json.RawMessage
+	var apiName, method string
+	if json.Unmarshal(raw, &call) != nil || len(call) != 3 ||
+		json.Unmarshal(call[0], &apiName) != nil || json.Unmarshal(call[1], &method) != nil {
+		return "", nil, false
+	}
+	return apiName + "." + method, call[2], true
+}
+
+// blockParam returns the block number block method params ask for.
+func blockParam(params string) uint32 {
+	var positional []json.RawMessage
+	if json.Unmarshal([]byte(params), &positional) == nil {
+		var num uint32
+		if len(positional) > 0 && json.Unmarshal(positional[0], &num) == nil {
+			return num
+		}
+		return 0
+	}
+	var named struct {
+		BlockNum uint32 `json:"block_num"`
+	}
+	json.Unmarshal([]byte(params), &named)
+	return named.BlockNum
+}
+
+func (c *Cache) getLocked(key string) (json.RawMessage, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.removeLocked(e)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return entry.result, true
+}
+
+// storeLocked caches resp if it is a successful response worth keeping, and
+// picks up the irreversible block from dynamic global properties.
+func (c *Cache) storeLocked(key, method, params string, resp *RPCResponse) {
+	if resp == nil || resp.HasError() || len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return
+	}
+	if method == "condenser_api.get_dynamic_global_properties" || method == "database_api.get_dynamic_global_properties" {
+		var dgp struct {
+			LastIrreversibleBlockNum uint32 `json:"last_irreversible_block_num"`
+		}
+		if json.Unmarshal(resp.Result, &dgp) == nil && dgp.LastIrreversibleBlockNum > c.irreversible {
+			c.irreversible = dgp.LastIrreversibleBlockNum
+		}
+	}
+
+	entry := &cacheEntry{key: key, result: resp.Result}
+	if blockMethods[method] {
+		entry.block = blockParam(params)
+		if entry.block == 0 || entry.block > c.irreversible {
+			return
+		}
+	} else {
+		entry.expires = time.Now().Add(c.ttls[method])
+	}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+func (c *Cache) removeLocked(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).key)
+}
+
+// cachedResponse answers req with a cached result.
+func cachedResponse(req *RPCRequest, result json.RawMessage) *RPCResponse {
+	return &RPCResponse{JsonRpc: "2.0", ID: req.ID, Result: result}
+}
+
+// withID copies resp for the request with id.
+func withID(resp *RPCResponse, id uint64) *RPCResponse {
+	copied := *resp
+	copied.ID = id
+	return &copied
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// cacheNode returns a node and a transport to it. A non-nil release holds
+// every request back until it is closed.
+func cacheNode(t *testing.T, release <-chan struct{}, opts ...steemtest.Option) (*steemtest.Node, Transport) {
+	node := steemtest.NewNode(opts...)
+	t.Cleanup(node.Close)
+	transport := NewHTTPTransport(node.URL, nil)
+	if release == nil {
+		return node, transport
+	}
+	return node, funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		<-release
+		return transport.Send(ctx, req)
+	})
+}
+
+func TestCache_TTL(t *testing.T) {
+	node, transport := cacheNode(t, nil)
+	cache := NewCache(transport, WithCacheTTL("condenser_api.get_accounts", 50*time.Millisecond))
+	api := NewAPI(node.URL, WithTransport(cache))
+	calls := func() int { return len(node.Requests()) }
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.GetAccounts([]string{"alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls() != 1 {
+		t.Fatalf("expected 1 node call, got %d", calls())
+	}
+	api.GetAccounts([]string{"bob"})
+	if calls() != 2 {
+		t.Fatalf("different params must miss; got %d calls", calls())
+	}
+	time.Sleep(60 * time.Millisecond)
+	api.GetAccounts([]string{"alice"})
+	if calls() != 3 {
+		t.Fatalf("expired entry must miss; got %d calls", calls())
+	}
+	// Uncached methods pass straight through.
+	api.LookupAccounts("a", 10)
+	api.LookupAccounts("a", 10)
+	if calls() != 5 {
+		t.Fatalf("expected lookups to pass through, got %d calls", calls())
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCache_LRU(t *testing.T) {
+	node, transport := cacheNode(t, nil)
+	api := NewAPI(node.URL, WithTransport(transport), WithCache(WithCacheSize(2)))
+
+	for _, name := range []string{"a", "b", "a", "c", "a", "b"} {
+		api.GetAccounts([]string{name})
+	}
+	// a, b miss; a hits; c misses and evicts b; a hits; b misses.
+	if got := len(node.Requests()); got != 4 {
+		t.Errorf("expected 4 node calls, got %d", got)
+	}
+}
+
+func TestCache_Coalescing(t *testing.T) {
+	release := make(chan struct{})
+	node, transport := cacheNode(t, release)
+	cache := NewCache(transport)
+	api := NewAPI(node.URL, WithTransport(cache))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.GetFollowCount("alice"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	for cache.Stats().Coalesced < 9 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if got := len(node.Requests()); got != 1 {
+		t.Errorf("expected 1 node call, got %d", got)
+	}
+}
+
+func TestCache_IrreversibleBlocks(t *testing.T) {
+	node, transport := cacheNode(t, nil, steemtest.WithIrreversibleLag(1))
+	node.AdvanceBlocks(100)
+	cache := NewCache(transport)
+	api := NewAPI(node.URL, WithTransport(cache))
+	calls := func() int { return len(node.Requests()) }
+
+	api.GetBlock(100)
+	api.GetBlock(100)
+	if calls() != 2 {
+		t.Fatalf("blocks must not be cached before the irreversible block is known; got %d calls", calls())
+	}
+	// Head block 101, last irreversible block 100.
+	if _, err := api.GetDynamicGlobalProperties(); err != nil {
+		t.Fatal(err)
+	}
+	api.GetBlock(100)
+	api.GetBlock(100)
+	api.GetBlock(101)
+	api.GetBlock(101)
+	// dgp, 100 once more, then 101 twice because it is still reversible.
+	if calls() != 6 {
+		t.Fatalf("expected 6 node calls, got %d", calls())
+	}
+
+	cache.InvalidateBlocks(100)
+	api.GetBlock(100)
+	if calls() != 7 {
+		t.Fatalf("invalidated block must miss; got %d calls", calls())
+	}
+	cache.SetIrreversible(101)
+	api.GetBlock(101)
+	api.GetBlock(101)
+	if calls() != 8 {
+		t.Errorf("expected 8 node calls, got %d", calls())
+	}
+}
+
+func TestCache_LegacyCallForm(t *testing.T) {
+	node, transport := cacheNode(t, nil)
+	api := NewAPI(node.URL, WithTransport(transport), WithLegacyCallForm(), WithCache())
+
+	api.GetFollowCount("alice")
+	api.GetFollowCount("alice")
+	if got := len(node.Requests()); got != 1 {
+		t.Errorf("expected 1 node call, got %d", got)
+	}
+}
+
+func TestCache_Batch(t *testing.T) {
+	node, transport := cacheNode(t, nil)
+	cache := NewCache(transport)
+	api := NewAPI(node.URL, WithTransport(cache))
+
+	api.GetFollowCount("alice")
+	batch := api.NewBatch()
+	var first, second json.RawMessage
+	batch.Add("condenser_api", "get_follow_count", []interface{}{"alice"}, &first)
+	batch.Add("condenser_api", "get_follow_count", []interface{}{"bob"}, &second)
+	if err := batch.Do(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := len(node.Requests()); calls != 2 || len(first) == 0 || len(second) == 0 {
+		t.Errorf("expected alice from the cache and bob from the node; %d calls", calls)
+	}
+}
+
+func TestCache_SignedCallValidation(t *testing.T) {
+	cache := NewCache(NewHTTPTransport("http://a", nil))
+	if err := NewAPI("http://a", WithTransport(cache)).validateTransportForSignedCall(); err != nil {
+		t.Errorf("cache over HTTP should allow signed calls, got %v", err)
+	}
+
+	cache = NewCache(NewWebSocketTransport("ws://a"))
+	if err := NewAPI("http://a", WithTransport(cache)).validateTransportForSignedCall(); err == nil {
+		t.Error("cache over a WebSocket transport must reject signed calls")
+	}
+
+	pool, _ := NewPool([]string{"http://a", "wss://b"})
+	if err := NewAPI("http://a", WithTransport(NewCache(pool))).validateTransportForSignedCall(); err == nil {
+		t.Error("cache over a pool containing a WebSocket node must reject signed calls")
+	}
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// AllOps is the Checkpoint.OpIndex recorded once every operation of a block
+// has been processed.
+const AllOps = -1
+
+// Checkpoint is the position of the last work a stream consumer acknowledged.
+type Checkpoint struct {
+	// BlockNum is the last block with acknowledged work.
+	BlockNum uint `json:"block_num"`
+	// OpIndex is the position of the last acknowledged operation in the
+	// block's full get_ops_in_block list (before filtering), or AllOps when
+	// the whole block was processed.
+	OpIndex int `json:"op_index"`
+}
+
+// Checkpointer stores the position of a stream so that a restarted process
+// can resume where it left off. Implementations must be safe for concurrent
+// use.
+type Checkpointer interface {
+	// Load returns the saved checkpoint; ok is false if none was saved yet.
+	Load(ctx context.Context) (cp Checkpoint, ok bool, err error)
+	// Save durably records cp, replacing the previous checkpoint.
+	Save(ctx context.Context, cp Checkpoint) error
+}
+
+// FileCheckpointer is a Checkpointer that keeps the checkpoint as JSON in a
+// single file. Saves write a temporary file and rename it over the old one,
+// so a crash never leaves a torn checkpoint behind.
+type FileCheckpointer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointer returns a FileCheckpointer that stores its checkpoint
+// at path. The file is created on the first Save.
+func NewFileCheckpointer(path string) *FileCheckpointer {
+	return &FileCheckpointer{path: path}
+}
+
+// Load reads the checkpoint file. A missing file means no checkpoint.
+func (f *FileCheckpointer) Load(ctx context.Context) (Checkpoint, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var cp Checkpoint
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return cp, false, nil
+	}
+	if err != nil {
+		return cp, false, errors.Wrap(err, "failed to read checkpoint")
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, false, errors.Wrapf(err, "failed to decode checkpoint %s", f.path)
+	}
+	return cp, true, nil
+}
+
+// Save writes cp to the checkpoint file.
+func (f *FileCheckpointer) Save(ctx context.Context, cp Checkpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return errors.Wrap(err, "failed to save checkpoint")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to save checkpoint")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to save checkpoint")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to save checkpoint")
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return errors.Wrap(err, "failed to save checkpoint")
+	}
+	return nil
+}
+
+// WithCheckpointer makes StreamBlocks and StreamOperations resume from the
+// checkpoint in cp instead of the from argument, when one was saved, and
+// record progress in cp as the consumer calls Ack.
+//
+// Delivery is at-least-once: everything after the last acknowledged block or
+// operation is delivered again after a restart, so handlers must tolerate
+// repeats. Ack covers every item delivered before the one acknowledged, so
+// not every item needs its own Ack, but the stream only remembers the last
+// 1000 unacknowledged items: ack at least one item in every 1000 delivered,
+// or the older ones can no longer be acknowledged. FollowChain ignores this
+// option.
+func WithCheckpointer(cp Checkpointer) StreamOption {
+	return func(c *streamConfig) {
+		c.checkpointer = cp
+	}
+}
+
+// maxPendingAcks bounds the delivered but unacknowledged items an acker
+// remembers, so a consumer that never calls Ack does not grow it forever.
+const maxPendingAcks = 1000
+
+// acker tracks delivered stream items until the consumer acknowledges them,
+// and saves the position of the newest acknowledged item.
+type acker struct {
+	cp      Checkpointer
+	mu      sync.Mutex
+	pending []pendingAck // delivered but not yet acknowledged, in order
+	saved   Checkpoint
+}
+
+type pendingAck struct {
+	item interface{}
+	pos  Checkpoint
+}
+
+// track registers item, about to be delivered, at position pos.
+func (k *acker) track(item interface{}, pos Checkpoint) {
+	if k.cp == nil {
+		return
+	}
+	k.mu.Lock()
+	if len(k.pending) == maxPendingAcks {
+		// Acking any later item still covers the forgotten one.
+		k.pending[0] = pendingAck{}
+		k.pending = k.pending[1:]
+	}
+	k.pending = append(k.pending, pendingAck{item, pos})
+	k.mu.Unlock()
+}
+
+// ack acknowledges item and everything delivered before it.
+func (k *acker) ack(item interface{}) error {
+	if k.cp == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i, p := range k.pending {
+		if p.item == item {
+			k.pending = k.pending[i+1:]
+			return k.saveLocked(p.pos)
+		}
+	}
+	return errors.Errorf("ack of an item that was not delivered, was already acknowledged or is more than %d items old", maxPendingAcks)
+}
+
+// idle records that every block up to blockNum was delivered in full. It
+// only saves when nothing delivered is still unacknowledged, so the
+// checkpoint never skips unprocessed work.
+func (k *acker) idle(blockNum uint) error {
+	if k.cp == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.pending) > 0 || blockNum < k.saved.BlockNum {
+		return nil
+	}
+	if blockNum == k.saved.BlockNum && k.saved.OpIndex == AllOps {
+		return nil
+	}
+	return k.saveLocked(Checkpoint{BlockNum: blockNum, OpIndex: AllOps})
+}
+
+func (k *acker) saveLocked(pos Checkpoint) error {
+	if err := k.cp.Save(context.Background(), pos); err != nil {
+		return err
+	}
+	k.saved = pos
+	return nil
+}
+
+// resume loads the checkpoint and returns the block to start from and, for
+// operation streams, how many leading operations of that block to skip.
+func (k *acker) resume(ctx context.Context, from uint) (uint, int, error) {
+	if k.cp == nil {
+		return from, 0, nil
+	}
+	cp, ok, err := k.cp.Load(ctx)
+	if err != nil || !ok {
+		return from, 0, err
+	}
+	k.saved = cp
+	if cp.OpIndex == AllOps {
+		return cp.BlockNum + 1, 0, nil
+	}
+	return cp.BlockNum, cp.OpIndex + 1, nil
+}
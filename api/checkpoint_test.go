@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steemit/steemutil/protocol"
+)
+
+func TestFileCheckpointer(t *testing.T) {
+	dir := t.TempDir()
+	cp := NewFileCheckpointer(filepath.Join(dir, "stream.json"))
+	ctx := context.Background()
+
+	if _, ok, err := cp.Load(ctx); ok || err != nil {
+		t.Fatalf("expected no checkpoint, got ok=%v err=%v", ok, err)
+	}
+	if err := cp.Save(ctx, Checkpoint{BlockNum: 42, OpIndex: 3}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := cp.Save(ctx, Checkpoint{BlockNum: 43, OpIndex: AllOps}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, ok, err := NewFileCheckpointer(filepath.Join(dir, "stream.json")).Load(ctx)
+	if err != nil || !ok || got != (Checkpoint{BlockNum: 43, OpIndex: AllOps}) {
+		t.Errorf("unexpected checkpoint %+v ok=%v err=%v", got, ok, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the checkpoint file, got %d entries", len(entries))
+	}
+}
+
+func TestFileCheckpointer_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.json")
+	os.WriteFile(path, []byte("{"), 0o644)
+	if _, _, err := NewFileCheckpointer(path).Load(context.Background()); err == nil {
+		t.Error("expected an error for a corrupt checkpoint")
+	}
+}
+
+func TestStreamOperations_ResumesAfterLastAck(t *testing.T) {
+	chain := newStandInChain(t, 12, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	cp := NewFileCheckpointer(filepath.Join(t.TempDir(), "ops.json"))
+	opts := []StreamOption{WithStreamMode(StreamHead), WithStreamInterval(time.Millisecond), WithCheckpointer(cp)}
+
+	// First run: handle block 10 and the first two ops of block 11, then crash.
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := api.StreamOperations(ctx, 10, OperationFilter{}, opts...)
+	var last *protocol.OperationObject
+	for _, op := range collectOps(t, stream, 7) {
+		if op.BlockNumber == 11 && op.TransactionInBlock == 1 {
+			last = op
+		}
+	}
+	if err := stream.Ack(last); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	cancel()
+	for range stream.C {
+	}
+
+	saved, _, _ := cp.Load(context.Background())
+	if saved != (Checkpoint{BlockNum: 11, OpIndex: 1}) {
+		t.Fatalf("unexpected checkpoint %+v", saved)
+	}
+
+	// Second run ignores from and resumes right after the acknowledged op.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stream = api.StreamOperations(ctx, 1, OperationFilter{}, opts...)
+	op := collectOps(t, stream, 1)[0]
+	if op.BlockNumber != 11 || op.Operation.Type() != "future_operation" {
+		t.Errorf("expected to resume at block 11 op 2, got %s in block %d", op.Operation.Type(), op.BlockNumber)
+	}
+}
+
+func TestStreamOperations_CheckpointsIdleBlocks(t *testing.T) {
+	chain := newStandInChain(t, 12, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	cp := NewFileCheckpointer(filepath.Join(t.TempDir(), "ops.json"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	api.StreamOperations(ctx, 10, OperationFilter{Accounts: []string{"nobody"}},
+		WithStreamMode(StreamHead), WithStreamInterval(time.Millisecond), WithCheckpointer(cp))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, ok, _ := cp.Load(context.Background())
+		if ok && saved == (Checkpoint{BlockNum: 12, OpIndex: AllOps}) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected blocks without matches to be checkpointed, got %+v", saved)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamBlocks_ResumesAfterLastAck(t *testing.T) {
+	chain := newStandInChain(t, 20, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	cp := NewFileCheckpointer(filepath.Join(t.TempDir(), "blocks.json"))
+	opts := []StreamOption{WithStreamInterval(time.Millisecond), WithCheckpointer(cp)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := api.StreamBlocks(ctx, 1, StreamHead, opts...)
+	blocks := collectBlocks(t, stream, 8)
+	if err := stream.Ack(blocks[4]); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if err := stream.Ack(blocks[2]); err == nil {
+		t.Error("acknowledging an older block again should fail")
+	}
+	cancel()
+	for range stream.C {
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stream = api.StreamBlocks(ctx, 1, StreamHead, opts...)
+	if b := collectBlocks(t, stream, 1)[0]; b.BlockNum != 6 {
+		t.Errorf("expected to resume at block 6, got %d", b.BlockNum)
+	}
+}
+
+func TestStreamAck_WithoutCheckpointer(t *testing.T) {
+	chain := newStandInChain(t, 3, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := api.StreamBlocks(ctx, 1, StreamHead, WithStreamInterval(time.Millisecond))
+	if err := stream.Ack(collectBlocks(t, stream, 1)[0]); err != nil {
+		t.Errorf("Ack without a checkpointer should be a no-op, got %v", err)
+	}
+}
+
+type memCheckpointer struct{ cp Checkpoint }
+
+func (m *memCheckpointer) Load(ctx context.Context) (Checkpoint, bool, error) {
+	return m.cp, m.cp != Checkpoint{}, nil
+}
+
+func (m *memCheckpointer) Save(ctx context.Context, cp Checkpoint) error {
+	m.cp = cp
+	return nil
+}
+
+func TestAcker_BoundsPending(t *testing.T) {
+	cp := &memCheckpointer{}
+	k := &acker{cp: cp}
+	items := make([]*int, maxPendingAcks+10)
+	for i := range items {
+		items[i] = new(int)
+		k.track(items[i], Checkpoint{BlockNum: uint(i + 1), OpIndex: AllOps})
+	}
+	if len(k.pending) != maxPendingAcks {
+		t.Fatalf("expected %d pending items, got %d", maxPendingAcks, len(k.pending))
+	}
+	if err := k.ack(items[0]); err == nil {
+		t.Error("expected an error acking a forgotten item")
+	}
+	if err := k.ack(items[20]); err != nil {
+		t.Fatalf("ack failed: %v", err)
+	}
+	if cp.cp.BlockNum != 21 || len(k.pending) != len(items)-21 {
+		t.Errorf("unexpected checkpoint %+v with %d pending", cp.cp, len(k.pending))
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// slowNode returns a node that holds every request until the client goes
+// away (or the test ends), so the only way a call can return is through
+// context cancellation.
+func slowNode(t *testing.T) *steemtest.Node {
+	t.Helper()
+	node := steemtest.NewNode()
+	node.SetLatency(time.Hour)
+	t.Cleanup(node.Close)
+	return node
+}
+
+func TestCallContext_Cancel(t *testing.T) {
+	api := NewAPI(slowNode(t).URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.CallContext(ctx, "condenser_api", "get_dynamic_global_properties", []interface{}{})
+	if err == nil {
+		t.Fatal("expected error from cancelled call, got nil")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded in chain, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call did not abort promptly: took %v", elapsed)
+	}
+}
+
+func TestGetAccountsContext(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddAccount("alice", nil)
+	api := NewAPI(node.URL)
+
+	accts, err := api.GetAccountsContext(context.Background(), []string{"alice"})
+	if err != nil {
+		t.Fatalf("GetAccountsContext failed: %v", err)
+	}
+	if len(accts) != 1 || accts[0].Name != "alice" {
+		t.Errorf("unexpected accounts: %+v", accts)
+	}
+}
+
+// TestGetBlocksContext_Cancel checks that cancelling the context unblocks the
+// fan-out collector instead of waiting for every per-block goroutine.
+func TestGetBlocksContext_Cancel(t *testing.T) {
+	api := NewAPI(slowNode(t).URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	blocks, err := api.GetBlocksContext(ctx, 1, 5)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if blocks != nil {
+		t.Errorf("expected no blocks on cancellation, got %d", len(blocks))
+	}
+}
+
+func TestGetOpsInBlocksContext_Cancel(t *testing.T) {
+	api := NewAPI(slowNode(t).URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := api.GetOpsInBlocksContext(ctx, 1, 3, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
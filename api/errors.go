@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Sentinel errors for common steemd failures. An *RPCError matches the
+// sentinel its node error was classified as, so callers can branch with
+// errors.Is regardless of how the error was wrapped:
+//
+//	if errors.Is(err, api.ErrDuplicateTransaction) {
+//		// already on chain, nothing to do
+//	}
+var (
+	// ErrMissingAuthority means the transaction lacks a signature for a
+	// required posting, active or owner authority: re-sign with the right key.
+	ErrMissingAuthority = errors.New("missing required authority")
+	// ErrDuplicateTransaction means an identical transaction is already
+	// known to the node.
+	ErrDuplicateTransaction = errors.New("duplicate transaction")
+	// ErrExpiredTransaction means the transaction expiration has passed:
+	// rebuild it with a fresh reference block and sign again.
+	ErrExpiredTransaction = errors.New("transaction expired")
+	// ErrInsufficientRC means the account does not have enough resource
+	// credits; retry after they regenerate.
+	ErrInsufficientRC = errors.New("insufficient resource credits")
+	// ErrUnknownAccount means a referenced account does not exist.
+	ErrUnknownAccount = errors.New("unknown account")
+)
+
+// RPCError is a JSON-RPC error object returned by a node. Call, SignedCall,
+// batches and the broadcast package return it (possibly wrapped) whenever the
+// node answers with an error, so it can be recovered with errors.As.
+type RPCError struct {
+	// Method is the full method name of the failed call, e.g.
+	// "condenser_api.broadcast_transaction_synchronous".
+	Method  string
+	Code    int
+	Message string
+	// Data is the structured fc exception steemd attaches to assertion
+	// failures; nil when the node sent none or it could not be decoded.
+	Data *RPCErrorData
+	// Raw is the error object exactly as received.
+	Raw json.RawMessage
+
+	kind error
+}
+
+// RPCErrorData is the fc::exception steemd reports in the error data.
+type RPCErrorData struct {
+	Code    int                  `json:"code"`
+	Name    string               `json:"name"`
+	Message string               `json:"message"`
+	Stack   []RPCErrorStackFrame `json:"stack"`
+}
+
+// RPCErrorStackFrame is one entry of an fc exception stack: where the
+// assertion fired, its format string and the arguments for that format.
+type RPCErrorStackFrame struct {
+	Context struct {
+		Level     string `json:"level"`
+		File      string `json:"file"`
+		Line      int    `json:"line"`
+		Method    string `json:"method"`
+		Hostname  string `json:"hostname"`
+		Timestamp string `json:"timestamp"`
+	} `json:"context"`
+	Format string                 `json:"format"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// String returns the frame's format with ${name} placeholders replaced by
+// their arguments, the way steemd renders it in logs.
+func (f RPCErrorStackFrame) String() string {
+	return fcFormatArg.ReplaceAllStringFunc(f.Format, func(m string) string {
+		v, ok := f.Data[m[2:len(m)-1]]
+		if !ok {
+			return m
+		}
+		if s, ok := v.(string); ok {
+			return s
+		}
+		b, _ := json.Marshal(v)
+		return string(b)
+	})
+}
+
+var fcFormatArg = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// newRPCError decodes the error object raw returned for method.
+func newRPCError(method string, raw json.RawMessage) *RPCError {
+	e := &RPCError{Method: method, Raw: raw}
+	var obj struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		e.Code, e.Message = obj.Code, obj.Message
+		data := &RPCErrorData{}
+		if len(obj.Data) > 0 && json.Unmarshal(obj.Data, data) == nil {
+			e.Data = data
+		}
+	}
+	e.kind = classifyRPCError(e)
+	return e
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC error for %s: %s", e.Method, e.Raw)
+}
+
+// Is reports whether target is the sentinel this error was classified as.
+func (e *RPCError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+// Kind returns the sentinel error this error was classified as, or nil if it
+// matches none of them.
+func (e *RPCError) Kind() error {
+	return e.kind
+}
+
+// rpcErrorClasses maps lower-cased fragments of steemd error messages,
+// exception names and assertion formats to sentinels. The first match wins.
+var rpcErrorClasses = []struct {
+	kind  error
+	hints []string
+}{
+	{ErrMissingAuthority, []string{"missing required posting authority", "missing required active authority", "missing required owner authority", "missing required other authority", "tx_missing_"}},
+	{ErrDuplicateTransaction, []string{"duplicate transaction", "tx_duplicate_"}},
+	{ErrExpiredTransaction, []string{"now < trx.expiration", "transaction expired", "transaction has expired", "expired transaction"}},
+	{ErrInsufficientRC, []string{"rc, needs", "insufficient rc", "rc_plugin_exception", "not enough rc"}},
+	{ErrUnknownAccount, []string{"unknown account", "account does not exist", "account doesn't exist", "no such account"}},
+}
+
+func classifyRPCError(e *RPCError) error {
+	parts := []string{e.Message}
+	if e.Data != nil {
+		parts = append(parts, e.Data.Name, e.Data.Message)
+		for _, frame := range e.Data.Stack {
+			parts = append(parts, frame.String())
+		}
+	}
+	haystack := strings.ToLower(strings.Join(parts, "\n"))
+	for _, class := range rpcErrorClasses {
+		for _, hint := range class.hints {
+			if strings.Contains(haystack, hint) {
+				return class.kind
+			}
+		}
+	}
+	return nil
+}
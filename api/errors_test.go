@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// steemdAssert builds an error object shaped like steemd's fc exceptions.
+func steemdAssert(message, name, format string, data map[string]interface{}) string {
+	raw, _ := json.Marshal(map[string]interface{}{
+		"code":    -32000,
+		"message": message,
+		"data": map[string]interface{}{
+			"code":    10,
+			"name":    name,
+			"message": "Assert Exception",
+			"stack": []interface{}{map[string]interface{}{
+				"context": map[string]interface{}{"level": "error", "file": "database.cpp", "line": 3924, "method": "_apply_transaction"},
+				"format":  format,
+				"data":    data,
+			}},
+		},
+	})
+	return string(raw)
+}
+
+func TestRPCError_Classification(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{"missing posting", steemdAssert("missing required posting authority:Missing Posting Authority alice", "tx_missing_posting_auth", "missing required posting authority", nil), ErrMissingAuthority},
+		{"missing active", `{"code":-32000,"message":"Assert Exception:false: missing required active authority"}`, ErrMissingAuthority},
+		{"duplicate", steemdAssert("Assert Exception:itr == trx_idx.end(): Duplicate transaction check failed", "assert_exception", "Duplicate transaction check failed", nil), ErrDuplicateTransaction},
+		{"expired", steemdAssert("Assert Exception:now < trx.expiration: ", "assert_exception", "", map[string]interface{}{"now": "2024-01-01T00:00:00"}), ErrExpiredTransaction},
+		{"rc", steemdAssert("plugin exception:Account: alice has 100 RC, needs 2000 RC. Please wait to transact, or power up STEEM.", "plugin_exception", "Account: ${account} has ${rc_current} RC, needs ${rc_needed} RC.", map[string]interface{}{"account": "alice"}), ErrInsufficientRC},
+		{"unknown account", `{"code":-32000,"message":"Assert Exception:acnt != nullptr: unknown account","data":"not an object"}`, ErrUnknownAccount},
+		{"unclassified", `{"code":-32602,"message":"Invalid parameters"}`, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+				return &RPCResponse{ID: req.ID, Error: json.RawMessage(tc.raw)}, nil
+			})
+			api := NewAPI("http://node", WithTransport(transport))
+			_, err := api.Call("condenser_api", "broadcast_transaction_synchronous", []interface{}{})
+			err = errors.Wrap(err, "failed to broadcast")
+
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("expected *RPCError in chain, got %T: %v", err, err)
+			}
+			if rpcErr.Method != "condenser_api.broadcast_transaction_synchronous" {
+				t.Errorf("unexpected method %q", rpcErr.Method)
+			}
+			if got := rpcErr.Kind(); got != tc.want {
+				t.Errorf("Kind() = %v, want %v", got, tc.want)
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("errors.Is(err, %v) = false", tc.want)
+			}
+			if tc.want != ErrDuplicateTransaction && errors.Is(err, ErrDuplicateTransaction) {
+				t.Error("error must not match an unrelated sentinel")
+			}
+			if IsRetryable(err) {
+				t.Error("deterministic RPC errors must not be retryable")
+			}
+		})
+	}
+}
+
+func TestRPCError_StructuredData(t *testing.T) {
+	raw := steemdAssert("plugin exception", "plugin_exception",
+		"Account: ${account} has ${rc_current} RC, needs ${rc_needed} RC.",
+		map[string]interface{}{"account": "alice", "rc_current": 100, "rc_needed": "2000"})
+	rpcErr := newRPCError("condenser_api.broadcast_transaction", json.RawMessage(raw))
+
+	if rpcErr.Code != -32000 || rpcErr.Message != "plugin exception" {
+		t.Errorf("unexpected code/message: %d %q", rpcErr.Code, rpcErr.Message)
+	}
+	if rpcErr.Data == nil || rpcErr.Data.Name != "plugin_exception" || len(rpcErr.Data.Stack) != 1 {
+		t.Fatalf("unexpected data: %+v", rpcErr.Data)
+	}
+	frame := rpcErr.Data.Stack[0]
+	if frame.Context.File != "database.cpp" || frame.Context.Line != 3924 {
+		t.Errorf("unexpected context: %+v", frame.Context)
+	}
+	if got, want := frame.String(), "Account: alice has 100 RC, needs 2000 RC."; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !errors.Is(rpcErr, ErrInsufficientRC) {
+		t.Error("expected the formatted stack to classify as insufficient RC")
+	}
+	if string(rpcErr.Raw) != raw {
+		t.Error("Raw must keep the error object as received")
+	}
+}
+
+func TestRPCError_NodeTroubleRetryable(t *testing.T) {
+	err := newRPCError("condenser_api.get_block", json.RawMessage(`{"code":-32603,"message":"Internal Error"}`))
+	if !IsRetryable(err) {
+		t.Error("node-side RPC errors should be retryable")
+	}
+}
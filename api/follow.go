@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	protocolapi "github.com/steemit/steemutil/protocol/api"
+)
+
+// MaxFollowPage is the largest page condenser_api.get_followers and
+// get_following serve.
+const MaxFollowPage = 1000
+
+// FollowIterator pages through the followers or followings of an account.
+// It is not safe for concurrent use.
+type FollowIterator struct {
+	ctx      context.Context
+	fetch    func(ctx context.Context, start string, limit int) ([]*protocolapi.FollowReturn, error)
+	key      func(*protocolapi.FollowReturn) string
+	pageSize int
+	start    string
+	more     bool
+	paged    bool
+	buf      []*protocolapi.FollowReturn
+	entry    *protocolapi.FollowReturn
+	err      error
+}
+
+// IterateFollowers walks every account following account with followType
+// ("blog" or "ignore"), in name order, fetching pages of pageSize entries
+// (MaxFollowPage if pageSize <= 0 or larger) as they are needed:
+//
+//	it := a.IterateFollowers(ctx, "alice", "blog", 0)
+//	for it.Next() {
+//		fmt.Println(it.Entry().Follower)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Each page starts at the last name of the previous one; that repeated entry
+// is skipped.
+func (a *API) IterateFollowers(ctx context.Context, account, followType string, pageSize int) *FollowIterator {
+	return newFollowIterator(ctx, pageSize, func(ctx context.Context, start string, limit int) ([]*protocolapi.FollowReturn, error) {
+		return a.GetFollowersContext(ctx, account, start, followType, limit)
+	}, func(f *protocolapi.FollowReturn) string {
+		return f.Follower
+	})
+}
+
+// IterateFollowing walks every account that account follows with
+// followType, like IterateFollowers.
+func (a *API) IterateFollowing(ctx context.Context, account, followType string, pageSize int) *FollowIterator {
+	return newFollowIterator(ctx, pageSize, func(ctx context.Context, start string, limit int) ([]*protocolapi.FollowReturn, error) {
+		return a.GetFollowingContext(ctx, account, start, followType, limit)
+	}, func(f *protocolapi.FollowReturn) string {
+		return f.Following
+	})
+}
+
+func newFollowIterator(ctx context.Context, pageSize int,
+	fetch func(ctx context.Context, start string, limit int) ([]*protocolapi.FollowReturn, error),
+	key func(*protocolapi.FollowReturn) string) *FollowIterator {
+	if pageSize <= 0 || pageSize > MaxFollowPage {
+		pageSize = MaxFollowPage
+	}
+	if pageSize < 2 {
+		pageSize = 2 // a page must hold more than the boundary entry
+	}
+	return &FollowIterator{ctx: ctx, fetch: fetch, key: key, pageSize: pageSize, more: true}
+}
+
+// Next advances to the next entry. It returns false when every entry was
+// visited or a page failed; check Err afterwards.
+func (it *FollowIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.err != nil || !it.more {
+			return false
+		}
+		it.err = it.fetchPage()
+	}
+	it.entry = it.buf[0]
+	it.buf = it.buf[1:]
+	return true
+}
+
+// Entry returns the entry Next advanced to.
+func (it *FollowIterator) Entry() *protocolapi.FollowReturn {
+	return it.entry
+}
+
+// Err returns the error that ended the walk, if any.
+func (it *FollowIterator) Err() error {
+	return it.err
+}
+
+func (it *FollowIterator) fetchPage() error {
+	page, err := it.fetch(it.ctx, it.start, it.pageSize)
+	if err != nil {
+		return errors.Wrapf(err, "failed to page follows from %q", it.start)
+	}
+	// A short page is the last one.
+	it.more = len(page) == it.pageSize
+	if it.paged && len(page) > 0 && it.key(page[0]) == it.start {
+		page = page[1:] // the boundary entry ended the previous page
+	}
+	if len(page) > 0 {
+		it.start = it.key(page[len(page)-1])
+	} else {
+		it.more = false
+	}
+	it.paged = true
+	it.buf = page
+	return nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// followNode returns a node where followers follow alice.
+func followNode(t *testing.T, followers []string) *steemtest.Node {
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	for _, name := range followers {
+		node.Follow(name, "alice")
+	}
+	return node
+}
+
+// followerStarts returns the start name of every get_followers request node
+// received.
+func followerStarts(t *testing.T, node *steemtest.Node) []string {
+	var starts []string
+	for _, req := range node.Requests() {
+		if req.Method != "condenser_api.get_followers" {
+			t.Errorf("unexpected method %s", req.Method)
+			continue
+		}
+		var params []interface{}
+		json.Unmarshal(req.Params, &params)
+		starts = append(starts, params[1].(string))
+	}
+	return starts
+}
+
+func TestIterateFollowers_Paging(t *testing.T) {
+	var followers []string
+	for i := 0; i < 7; i++ {
+		followers = append(followers, fmt.Sprintf("user%d", i))
+	}
+	node := followNode(t, followers)
+	api := NewAPI(node.URL)
+
+	it := api.IterateFollowers(context.Background(), "alice", "blog", 3)
+	var got []string
+	for it.Next() {
+		got = append(got, it.Entry().Follower)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(followers) {
+		t.Fatalf("expected %v, got %v", followers, got)
+	}
+	// Pages [0 1 2], [2 3 4], [4 5 6] and the short [6].
+	if starts, want := followerStarts(t, node), "[ user2 user4 user6]"; fmt.Sprint(starts) != want {
+		t.Errorf("expected starts %s, got %v", want, starts)
+	}
+}
+
+func TestIterateFollowers_ShortFirstPage(t *testing.T) {
+	node := followNode(t, []string{"bob", "carol"})
+	api := NewAPI(node.URL)
+
+	it := api.IterateFollowers(context.Background(), "alice", "blog", 0)
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if it.Err() != nil || n != 2 {
+		t.Fatalf("expected 2 followers, got %d (%v)", n, it.Err())
+	}
+	if starts := followerStarts(t, node); len(starts) != 1 {
+		t.Errorf("expected a single request, got %d", len(starts))
+	}
+}
+
+func TestIterateFollowing_Error(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{ID: req.ID, Error: json.RawMessage(`{"code":-32602,"message":"Invalid parameters"}`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport), fastRetry(1))
+
+	it := api.IterateFollowing(context.Background(), "alice", "blog", 10)
+	if it.Next() {
+		t.Fatal("expected no entries")
+	}
+	if it.Err() == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrForkTooDeep is returned by FollowChain when the node switched to a fork
+// whose common ancestor with the delivered chain is older than the fork depth
+// the follower keeps (see WithForkDepth).
+var ErrForkTooDeep = errors.New("fork deeper than the tracked block window")
+
+// ChainEventType says what a ChainEvent reports.
+type ChainEventType int
+
+const (
+	// ChainBlock reports a new block extending the chain.
+	ChainBlock ChainEventType = iota
+	// ChainRollback reports that previously delivered blocks were orphaned
+	// by a fork and replaced.
+	ChainRollback
+)
+
+func (t ChainEventType) String() string {
+	switch t {
+	case ChainBlock:
+		return "block"
+	case ChainRollback:
+		return "rollback"
+	}
+	return "unknown"
+}
+
+// ChainEvent is one event of a FollowChain stream.
+type ChainEvent struct {
+	Type ChainEventType
+	// Block is the new block of a ChainBlock event.
+	Block *WrapBlock
+	// Orphaned lists the blocks of a ChainRollback event that are no longer
+	// on the chain, in ascending block order. Undo their effects, newest
+	// first.
+	Orphaned []*WrapBlock
+	// Replacements lists the blocks now on the chain in place of Orphaned,
+	// in ascending block order. Apply them; they are not delivered again as
+	// ChainBlock events.
+	Replacements []*WrapBlock
+}
+
+// ChainStream is a running fork-aware stream started by FollowChain. Receive
+// events from C; once C is closed, Err reports why the stream stopped.
+type ChainStream struct {
+	C <-chan *ChainEvent
+
+	streamState
+}
+
+// WithForkDepth sets how many recent blocks FollowChain remembers to find the
+// common ancestor of a fork. Steem forks cannot reach below the last
+// irreversible block, which trails the head by about 20 blocks, so the
+// default of 64 leaves ample room.
+func WithForkDepth(n int) StreamOption {
+	return func(c *streamConfig) {
+		if n > 0 {
+			c.forkDepth = n
+		}
+	}
+}
+
+// FollowChain follows the head block like StreamBlocks in StreamHead mode,
+// but checks that every block's previous id matches the block delivered
+// before it. When the node has switched forks, the follower walks back to the
+// common ancestor and emits a ChainRollback event naming the orphaned blocks
+// and their replacements before continuing with new blocks.
+//
+//	stream := a.FollowChain(ctx, 0)
+//	for ev := range stream.C {
+//		switch ev.Type {
+//		case api.ChainBlock:
+//			apply(ev.Block)
+//		case api.ChainRollback:
+//			for i := len(ev.Orphaned) - 1; i >= 0; i-- {
+//				undo(ev.Orphaned[i])
+//			}
+//			for _, b := range ev.Replacements {
+//				apply(b)
+//			}
+//		}
+//	}
+func (a *API) FollowChain(ctx context.Context, from uint, opts ...StreamOption) *ChainStream {
+	cfg := defaultStreamConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ch := make(chan *ChainEvent, cfg.buffer)
+	s := &ChainStream{C: ch, streamState: newStreamState()}
+	f := &forkFollower{api: a, ctx: ctx, depth: cfg.forkDepth, ch: ch}
+	go func() {
+		err := a.follow(ctx, from, StreamHead, cfg, f.round)
+		close(ch)
+		s.finish(err)
+	}()
+	return s
+}
+
+// forkFollower holds the recently delivered blocks of a FollowChain stream.
+type forkFollower struct {
+	api    *API
+	ctx    context.Context
+	depth  int
+	ch     chan<- *ChainEvent
+	window []*WrapBlock // delivered blocks, ascending and contiguous
+}
+
+func (f *forkFollower) round(next, end uint) (uint, error) {
+	blocks, err := f.api.GetBlocksContext(f.ctx, next, end)
+	if err != nil {
+		return 0, err
+	}
+	var delivered uint
+	for _, b := range blocks {
+		if b.Block == nil || b.Block.BlockId == "" {
+			break
+		}
+		if tip := f.tip(); tip != nil && b.Block.Previous != tip.Block.BlockId {
+			if err := f.rollback(b); err != nil {
+				return delivered, err
+			}
+			if tip := f.tip(); tip == nil || b.Block.Previous != tip.Block.BlockId {
+				// The node moved again while we walked back; re-poll.
+				return delivered, nil
+			}
+		}
+		f.push(b)
+		if err := f.emit(&ChainEvent{Type: ChainBlock, Block: b}); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// rollback handles b not linking to the tip: it fetches the canonical chain
+// backwards from b until it meets a block in the window, then reports the
+// blocks above that ancestor as orphaned.
+func (f *forkFollower) rollback(b *WrapBlock) error {
+	var replacements []*WrapBlock // newest first while walking
+	want := b.Block.Previous
+	for num := b.BlockNum - 1; ; num-- {
+		i := f.index(num)
+		if i < 0 {
+			return errors.Wrapf(ErrForkTooDeep, "no common ancestor for block %d within %d blocks", b.BlockNum, f.depth)
+		}
+		if f.window[i].Block.BlockId == want {
+			orphaned := append([]*WrapBlock(nil), f.window[i+1:]...)
+			if len(orphaned) == 0 {
+				return nil
+			}
+			for l, r := 0, len(replacements)-1; l < r; l, r = l+1, r-1 {
+				replacements[l], replacements[r] = replacements[r], replacements[l]
+			}
+			f.window = append(f.window[:i+1], replacements...)
+			return f.emit(&ChainEvent{Type: ChainRollback, Orphaned: orphaned, Replacements: replacements})
+		}
+		block, err := f.api.GetBlockContext(f.ctx, num)
+		if err != nil {
+			return err
+		}
+		if block.BlockId != want {
+			// The node switched forks again mid-walk; let the next poll
+			// start over from the new head.
+			return nil
+		}
+		replacements = append(replacements, &WrapBlock{BlockNum: num, Block: block})
+		want = block.Previous
+	}
+}
+
+func (f *forkFollower) tip() *WrapBlock {
+	if len(f.window) == 0 {
+		return nil
+	}
+	return f.window[len(f.window)-1]
+}
+
+// index returns the window position of block num, or -1.
+func (f *forkFollower) index(num uint) int {
+	if len(f.window) == 0 || num < f.window[0].BlockNum {
+		return -1
+	}
+	i := int(num - f.window[0].BlockNum)
+	if i >= len(f.window) {
+		return -1
+	}
+	return i
+}
+
+func (f *forkFollower) push(b *WrapBlock) {
+	f.window = append(f.window, b)
+	if len(f.window) > f.depth {
+		f.window = f.window[len(f.window)-f.depth:]
+	}
+}
+
+func (f *forkFollower) emit(ev *ChainEvent) error {
+	select {
+	case f.ch <- ev:
+		return nil
+	case <-f.ctx.Done():
+		return f.ctx.Err()
+	}
+}
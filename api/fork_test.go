@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// forkingChain is a stand-in node whose recent blocks can be replaced by a
+// competing fork. ids[n] is the id of block n on the current fork.
+type forkingChain struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func newForkingChain(head int) *forkingChain {
+	c := &forkingChain{ids: []string{""}}
+	c.extend(head, "a")
+	return c
+}
+
+// extend appends n blocks produced on fork tag.
+func (c *forkingChain) extend(n int, tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < n; i++ {
+		c.ids = append(c.ids, fmt.Sprintf("%08x%s", len(c.ids), tag))
+	}
+}
+
+// fork drops every block from n up and produces length blocks on fork tag.
+func (c *forkingChain) fork(n, length int, tag string) {
+	c.mu.Lock()
+	c.ids = c.ids[:n]
+	c.mu.Unlock()
+	c.extend(length, tag)
+}
+
+func (c *forkingChain) transport() Transport {
+	return funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		resp := &RPCResponse{ID: req.ID}
+		switch req.Method {
+		case "condenser_api.get_dynamic_global_properties":
+			resp.Result = json.RawMessage(fmt.Sprintf(`{"head_block_number":%d}`, len(c.ids)-1))
+		case "condenser_api.get_block":
+			num := int(req.Params.([]interface{})[0].(uint))
+			if num >= len(c.ids) {
+				resp.Result = json.RawMessage(`null`)
+				break
+			}
+			resp.Result = json.RawMessage(fmt.Sprintf(`{"block_id":%q,"previous":%q}`, c.ids[num], c.ids[num-1]))
+		}
+		return resp, nil
+	})
+}
+
+func nextEvent(t *testing.T, stream *ChainStream) *ChainEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-stream.C:
+		if !ok {
+			t.Fatalf("stream stopped: %v", stream.Err())
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return nil
+}
+
+func blockIDs(blocks []*WrapBlock) []string {
+	var ids []string
+	for _, b := range blocks {
+		ids = append(ids, b.Block.BlockId)
+	}
+	return ids
+}
+
+func TestFollowChain_Rollback(t *testing.T) {
+	chain := newForkingChain(10)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := api.FollowChain(ctx, 1, WithStreamInterval(time.Millisecond))
+	for n := 1; n <= 10; n++ {
+		ev := nextEvent(t, stream)
+		if ev.Type != ChainBlock || ev.Block.BlockNum != uint(n) {
+			t.Fatalf("expected block %d, got %s %+v", n, ev.Type, ev.Block)
+		}
+	}
+
+	// Blocks 8-10 are orphaned by a longer fork.
+	chain.fork(8, 5, "b")
+
+	ev := nextEvent(t, stream)
+	if ev.Type != ChainRollback {
+		t.Fatalf("expected a rollback, got %s", ev.Type)
+	}
+	if got := fmt.Sprint(blockIDs(ev.Orphaned)); got != "[00000008a 00000009a 0000000aa]" {
+		t.Errorf("unexpected orphaned blocks %s", got)
+	}
+	if got := fmt.Sprint(blockIDs(ev.Replacements)); got != "[00000008b 00000009b 0000000ab]" {
+		t.Errorf("unexpected replacements %s", got)
+	}
+	for n := 11; n <= 12; n++ {
+		ev := nextEvent(t, stream)
+		if ev.Type != ChainBlock || ev.Block.Block.BlockId != fmt.Sprintf("%08xb", n) {
+			t.Fatalf("expected block %d on the new fork, got %s %+v", n, ev.Type, ev.Block)
+		}
+	}
+}
+
+func TestFollowChain_ForkTooDeep(t *testing.T) {
+	chain := newForkingChain(10)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+
+	stream := api.FollowChain(context.Background(), 1, WithStreamInterval(time.Millisecond), WithForkDepth(3))
+	for n := 1; n <= 10; n++ {
+		nextEvent(t, stream)
+	}
+	chain.fork(5, 7, "b")
+
+	for range stream.C {
+		t.Fatal("no event expected for a fork beyond the window")
+	}
+	if err := stream.Err(); !errors.Is(err, ErrForkTooDeep) {
+		t.Errorf("expected ErrForkTooDeep, got %v", err)
+	}
+}
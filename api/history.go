@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/consts"
+)
+
+// MaxAccountHistoryPage is the largest page condenser_api.get_account_history
+// serves.
+const MaxAccountHistoryPage = 1000
+
+// operationIDs maps operation names to their position in steemd's operation
+// variant, which is also their bit in the operation_filter_low/high masks.
+var operationIDs = func() map[string]uint {
+	ids := make(map[string]uint, len(consts.OPERATION_NAMES))
+	for id, name := range consts.OPERATION_NAMES {
+		ids[name] = uint(id)
+	}
+	return ids
+}()
+
+// OperationFilterMask returns the operation_filter_low and
+// operation_filter_high masks of get_account_history that select the named
+// operation types.
+func OperationFilterMask(types []string) (low, high uint64, err error) {
+	for _, name := range types {
+		id, ok := operationIDs[name]
+		if !ok {
+			return 0, 0, errors.Errorf("unknown operation type %q", name)
+		}
+		if id < 64 {
+			low |= 1 << id
+		} else {
+			high |= 1 << (id - 64)
+		}
+	}
+	return low, high, nil
+}
+
+// HistoryDirection is the order in which IterateAccountHistory visits
+// entries.
+type HistoryDirection int
+
+const (
+	// HistoryBackward visits the newest entry first.
+	HistoryBackward HistoryDirection = iota
+	// HistoryForward visits the oldest entry first.
+	HistoryForward
+)
+
+// AccountHistoryOptions configures IterateAccountHistory. The zero value
+// walks the whole history from the newest entry back to the oldest.
+type AccountHistoryOptions struct {
+	Direction HistoryDirection
+	// Start is the index of the first entry visited. Nil starts at the
+	// newest entry when walking backwards and at the oldest when walking
+	// forwards.
+	Start *int64
+	// PageSize is the number of entries requested per call, at most
+	// MaxAccountHistoryPage (the default).
+	PageSize int
+	// Types keeps only these operation types, e.g. "transfer" or
+	// "author_reward". The node filters them with the operation_filter_low
+	// and operation_filter_high masks; see OperationFilterMask.
+	Types []string
+	// StopIndex ends the walk before the first entry past this index (below
+	// it walking backwards, above it walking forwards). Nil does not stop.
+	StopIndex *int64
+	// StopTime ends the walk before the first entry older (walking
+	// backwards) or newer (walking forwards) than this time. The zero time
+	// does not stop.
+	StopTime time.Time
+}
+
+// AccountHistoryIterator pages through an account's history. It is not safe
+// for concurrent use.
+type AccountHistoryIterator struct {
+	api       *API
+	ctx       context.Context
+	account   string
+	opts      AccountHistoryOptions
+	low, high uint64
+
+	started bool
+	next    int64 // edge of the next window to fetch; -1 once exhausted
+	newest  int64
+	buf     []*AccountHistoryEntry // current window in visiting order
+	entry   *AccountHistoryEntry
+	err     error
+}
+
+// IterateAccountHistory walks the history of account page by page in the
+// direction opts asks for, fetching pages as they are needed:
+//
+//	it := a.IterateAccountHistory(ctx, "alice", api.AccountHistoryOptions{
+//		Types:    []string{"transfer"},
+//		StopTime: time.Now().AddDate(0, -1, 0),
+//	})
+//	for it.Next() {
+//		e := it.Entry()
+//		fmt.Println(e.Index, e.Timestamp, e.Op.Payload["amount"])
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Pages are requested with get_account_history's boundary rules in mind:
+// a request for start s and limit l covers indices [s-l, s], and l never
+// exceeds s. Entries appended while walking forwards are not visited.
+func (a *API) IterateAccountHistory(ctx context.Context, account string, opts AccountHistoryOptions) *AccountHistoryIterator {
+	if opts.PageSize <= 0 || opts.PageSize > MaxAccountHistoryPage {
+		opts.PageSize = MaxAccountHistoryPage
+	}
+	it := &AccountHistoryIterator{api: a, ctx: ctx, account: account, opts: opts}
+	it.low, it.high, it.err = OperationFilterMask(opts.Types)
+	return it
+}
+
+// Next advances to the next entry. It returns false when the walk is over
+// or failed; check Err afterwards.
+func (it *AccountHistoryIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		if it.err = it.init(); it.err != nil {
+			return false
+		}
+	}
+	for len(it.buf) == 0 {
+		if it.next < 0 {
+			return false
+		}
+		if it.err = it.fetchWindow(); it.err != nil {
+			return false
+		}
+	}
+	e := it.buf[0]
+	it.buf = it.buf[1:]
+	if it.stopAt(e) {
+		it.buf, it.next = nil, -1
+		return false
+	}
+	it.entry = e
+	return true
+}
+
+// Entry returns the entry Next advanced to.
+func (it *AccountHistoryIterator) Entry() *AccountHistoryEntry {
+	return it.entry
+}
+
+// Err returns the error that ended the walk, if any.
+func (it *AccountHistoryIterator) Err() error {
+	return it.err
+}
+
+// init finds the newest index, which bounds both directions.
+func (it *AccountHistoryIterator) init() error {
+	page, err := it.page(-1, 1, 0, 0)
+	if err != nil {
+		return err
+	}
+	it.newest = -1
+	for _, e := range page {
+		if e.Index > it.newest {
+			it.newest = e.Index
+		}
+	}
+	switch {
+	case it.newest < 0:
+		it.next = -1 // no history
+	case it.opts.Direction == HistoryForward:
+		it.next = 0
+		if it.opts.Start != nil {
+			it.next = *it.opts.Start
+		}
+		if it.next > it.newest {
+			it.next = -1
+		}
+	default:
+		it.next = it.newest
+		if it.opts.Start != nil && *it.opts.Start < it.newest {
+			it.next = *it.opts.Start
+		}
+	}
+	return nil
+}
+
+// fetchWindow loads the next window of at most PageSize+1 indices into buf.
+func (it *AccountHistoryIterator) fetchWindow() error {
+	size := int64(it.opts.PageSize)
+	var lo, hi int64
+	if it.opts.Direction == HistoryForward {
+		lo, hi = it.next, it.next+size
+		if hi > it.newest {
+			hi = it.newest
+		}
+		it.next = hi + 1
+		if it.next > it.newest {
+			it.next = -1
+		}
+	} else {
+		lo, hi = it.next-size, it.next
+		if lo < 0 {
+			lo = 0
+		}
+		it.next = lo - 1
+	}
+
+	entries, err := it.window(lo, hi)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if it.opts.Direction == HistoryForward {
+			return entries[i].Index < entries[j].Index
+		}
+		return entries[i].Index > entries[j].Index
+	})
+	it.buf = entries
+	return nil
+}
+
+// window returns the entries with indices in [lo, hi]. Without a type filter
+// one request covers the window. With one, nodes may stop a page after limit
+// matching entries, so the window is walked down from hi until lo is
+// covered.
+func (it *AccountHistoryIterator) window(lo, hi int64) ([]*AccountHistoryEntry, error) {
+	var entries []*AccountHistoryEntry
+	for start := hi; start >= lo; {
+		limit := int64(it.opts.PageSize)
+		if limit > start {
+			limit = start // steemd requires start >= limit
+		}
+		page, err := it.page(start, int(limit), it.low, it.high)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			if e.Index >= lo && e.Index <= start {
+				entries = append(entries, e)
+			}
+		}
+		next := start - limit - 1
+		if len(page) > 0 && len(page) >= int(limit) {
+			// A filtered page may fill up with matches before reaching
+			// start-limit; carry on below its oldest entry.
+			next = minIndex(page) - 1
+		}
+		start = next
+	}
+	return entries, nil
+}
+
+func minIndex(page []*AccountHistoryEntry) int64 {
+	lowest := page[0].Index
+	for _, e := range page[1:] {
+		if e.Index < lowest {
+			lowest = e.Index
+		}
+	}
+	return lowest
+}
+
+func (it *AccountHistoryIterator) page(start int64, limit int, low, high uint64) ([]*AccountHistoryEntry, error) {
+	params := []interface{}{it.account, start, limit}
+	if low != 0 || high != 0 {
+		params = append(params, low, high)
+	}
+	page, err := CallTyped[[]*AccountHistoryEntry](it.ctx, it.api, "condenser_api", "get_account_history", params)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account history of %s from %d", it.account, start)
+	}
+	return page, nil
+}
+
+// stopAt reports whether e lies past StopIndex or StopTime.
+func (it *AccountHistoryIterator) stopAt(e *AccountHistoryEntry) bool {
+	forward := it.opts.Direction == HistoryForward
+	if stop := it.opts.StopIndex; stop != nil {
+		if forward && e.Index > *stop || !forward && e.Index < *stop {
+			return true
+		}
+	}
+	if !it.opts.StopTime.IsZero() {
+		ts, err := parseHistoryTime(e.Timestamp)
+		if err != nil {
+			return false
+		}
+		if forward && ts.After(it.opts.StopTime) || !forward && ts.Before(it.opts.StopTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHistoryTime parses steemd timestamps, which carry no zone and are UTC,
+// as well as RFC 3339 ones.
+func parseHistoryTime(s string) (time.Time, error) {
+	if ts, err := time.Parse("2006-01-02T15:04:05", s); err == nil {
+		return ts, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// historyNode returns a node whose account alice has n history entries,
+// alternating transfer and vote operations one hour apart.
+func historyNode(t *testing.T, n int64) *steemtest.Node {
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := int64(0); i < n; i++ {
+		op := steemtest.Op{Type: "transfer", Value: map[string]interface{}{"from": "alice", "to": "bob", "amount": "1.000 STEEM"}}
+		if i%2 == 1 {
+			op = steemtest.Op{Type: "vote", Value: map[string]interface{}{"voter": "alice", "author": "bob", "permlink": "p", "weight": 10000}}
+		}
+		node.AddHistoryEntry("alice", steemtest.HistoryEntry{Op: op, Timestamp: base.Add(time.Duration(i) * time.Hour)})
+	}
+	return node
+}
+
+func collectHistory(t *testing.T, it *AccountHistoryIterator) []int64 {
+	t.Helper()
+	var indices []int64
+	for it.Next() {
+		indices = append(indices, it.Entry().Index)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	return indices
+}
+
+func TestIterateAccountHistory_Backward(t *testing.T) {
+	node := historyNode(t, 25)
+	api := NewAPI(node.URL)
+
+	got := collectHistory(t, api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{PageSize: 10}))
+	if len(got) != 25 {
+		t.Fatalf("expected 25 entries, got %d: %v", len(got), got)
+	}
+	for i, idx := range got {
+		if idx != int64(24-i) {
+			t.Fatalf("position %d: got index %d, want %d", i, idx, 24-i)
+		}
+	}
+	// One probe plus windows [14,24], [3,13] and [0,2].
+	if got := len(node.Requests()); got != 4 {
+		t.Errorf("expected 4 calls, got %d", got)
+	}
+}
+
+func TestIterateAccountHistory_Forward(t *testing.T) {
+	node := historyNode(t, 25)
+	api := NewAPI(node.URL)
+
+	start := int64(3)
+	got := collectHistory(t, api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{
+		Direction: HistoryForward,
+		Start:     &start,
+		PageSize:  10,
+	}))
+	if len(got) != 22 || got[0] != 3 || got[21] != 24 {
+		t.Fatalf("expected indices 3..24, got %v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] != got[i-1]+1 {
+			t.Fatalf("entries out of order: %v", got)
+		}
+	}
+}
+
+func TestIterateAccountHistory_TypeFilter(t *testing.T) {
+	node := historyNode(t, 25)
+	api := NewAPI(node.URL)
+
+	it := api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{
+		Types:    []string{"transfer"},
+		PageSize: 4,
+	})
+	var got []int64
+	for it.Next() {
+		if it.Entry().Op.Type != "transfer" {
+			t.Fatalf("unexpected %s at %d", it.Entry().Op.Type, it.Entry().Index)
+		}
+		got = append(got, it.Entry().Index)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprint([]int64{24, 22, 20, 18, 16, 14, 12, 10, 8, 6, 4, 2, 0})
+	if fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIterateAccountHistory_Stops(t *testing.T) {
+	node := historyNode(t, 25)
+	api := NewAPI(node.URL)
+
+	stop := int64(20)
+	got := collectHistory(t, api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{StopIndex: &stop}))
+	if fmt.Sprint(got) != "[24 23 22 21 20]" {
+		t.Errorf("StopIndex: got %v", got)
+	}
+
+	// Index 0 is a valid Start and StopIndex.
+	zero := int64(0)
+	got = collectHistory(t, api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{Start: &zero}))
+	if fmt.Sprint(got) != "[0]" {
+		t.Errorf("Start 0 backwards: got %v", got)
+	}
+	got = collectHistory(t, api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{
+		Direction: HistoryForward,
+		StopIndex: &zero,
+	}))
+	if fmt.Sprint(got) != "[0]" {
+		t.Errorf("StopIndex 0 forwards: got %v", got)
+	}
+
+	got = collectHistory(t, api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{
+		Direction: HistoryForward,
+		StopTime:  time.Date(2026, 1, 1, 2, 30, 0, 0, time.UTC),
+	}))
+	if fmt.Sprint(got) != "[0 1 2]" {
+		t.Errorf("StopTime: got %v", got)
+	}
+}
+
+func TestIterateAccountHistory_UnknownType(t *testing.T) {
+	api := NewAPI(historyNode(t, 5).URL)
+	it := api.IterateAccountHistory(context.Background(), "alice", AccountHistoryOptions{Types: []string{"teleport"}})
+	if it.Next() || it.Err() == nil {
+		t.Error("expected an error for an unknown operation type")
+	}
+}
+
+func TestOperationFilterMask(t *testing.T) {
+	low, high, err := OperationFilterMask([]string{"vote", "transfer", "producer_reward", "sps_fund"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low != 1|1<<2|1<<61 || high != 1 {
+		t.Errorf("unexpected masks low=%#x high=%#x", low, high)
+	}
+}
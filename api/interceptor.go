@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Invoker sends a request on to the next interceptor, or finally to the
+// Transport.
+type Invoker func(ctx context.Context, req *RPCRequest) (*RPCResponse, error)
+
+// Interceptor observes or modifies a request on its way to the node. It must
+// call next to let the request through, and may change ctx or req before, or
+// the response and error after:
+//
+//	logging := func(ctx context.Context, req *api.RPCRequest, next api.Invoker) (*api.RPCResponse, error) {
+//		start := time.Now()
+//		resp, err := next(ctx, req)
+//		log.Printf("%s took %v (err %v)", req.Method, time.Since(start), err)
+//		return resp, err
+//	}
+//
+// Not calling next short-circuits the request, e.g. to inject faults in
+// tests. Interceptors see every request made by Call, the typed methods,
+// SignedCall and, through a Broadcast's API, BroadcastSync and friends, once
+// per call: retries happen inside next. Each call of a Batch passes through
+// them on its own, and the calls that reach the end of the chain are sent
+// together; headers set on their contexts are merged onto the batch request.
+type Interceptor func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error)
+
+// WithInterceptors adds interceptors to the chain every request passes
+// through. The first interceptor given is the outermost one; interceptors
+// from several WithInterceptors options are chained in option order.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(a *API) {
+		a.interceptors = append(a.interceptors, interceptors...)
+	}
+}
+
+// intercept runs req through the interceptor chain, ending in send.
+func (a *API) intercept(ctx context.Context, req *RPCRequest, send Invoker) (*RPCResponse, error) {
+	next := send
+	for i := len(a.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := a.interceptors[i], next
+		next = func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+			return interceptor(ctx, req, inner)
+		}
+	}
+	return next(ctx, req)
+}
+
+type headerKey struct{}
+
+// ContextWithHeader returns a copy of ctx that makes HTTPTransport add the
+// header key: value to the HTTP requests sent under it, e.g. from an
+// Interceptor injecting tracing or authentication headers. Other transports
+// ignore it.
+func ContextWithHeader(ctx context.Context, key, value string) context.Context {
+	header := http.Header{}
+	if parent, ok := ctx.Value(headerKey{}).(http.Header); ok {
+		header = parent.Clone()
+	}
+	header.Add(key, value)
+	return context.WithValue(ctx, headerKey{}, header)
+}
+
+// mergeContextHeaders returns ctx carrying, besides its own, the headers set
+// with ContextWithHeader on each of from. A value set on several contexts is
+// added once.
+func mergeContextHeaders(ctx context.Context, from ...context.Context) context.Context {
+	header := http.Header{}
+	if own, ok := ctx.Value(headerKey{}).(http.Header); ok {
+		header = own.Clone()
+	}
+	added := false
+	for _, c := range from {
+		extra, _ := c.Value(headerKey{}).(http.Header)
+		for key, values := range extra {
+			for _, value := range values {
+				if !containsString(header.Values(key), value) {
+					header.Add(key, value)
+					added = true
+				}
+			}
+		}
+	}
+	if !added {
+		return ctx
+	}
+	return context.WithValue(ctx, headerKey{}, header)
+}
+
+// setContextHeaders copies the headers set with ContextWithHeader onto r.
+func setContextHeaders(ctx context.Context, r *http.Request) {
+	header, _ := ctx.Value(headerKey{}).(http.Header)
+	for key, values := range header {
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+func TestInterceptors_Order(t *testing.T) {
+	var trace []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error) {
+			trace = append(trace, name+">")
+			resp, err := next(ctx, req)
+			trace = append(trace, "<"+name)
+			return resp, err
+		}
+	}
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		trace = append(trace, "send")
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport),
+		WithInterceptors(record("a"), record("b")), WithInterceptors(record("c")))
+
+	if _, err := api.Call("condenser_api", "get_config", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(trace, " "); got != "a> b> c> send <c <b <a" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
+
+func TestInterceptors_RewriteAndShortCircuit(t *testing.T) {
+	var sent []string
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		sent = append(sent, req.Method)
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`[]`)}, nil
+	})
+	injected := errors.New("injected fault")
+	api := NewAPI("http://node", WithTransport(transport), WithInterceptors(
+		func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error) {
+			if req.Method == "condenser_api.get_config" {
+				return nil, injected
+			}
+			rewritten := *req
+			rewritten.Method = strings.Replace(req.Method, "condenser_api", "database_api", 1)
+			return next(ctx, &rewritten)
+		}))
+
+	if _, err := api.LookupAccounts("a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0] != "database_api.lookup_accounts" {
+		t.Errorf("expected the rewritten method, got %v", sent)
+	}
+	if _, err := api.Call("condenser_api", "get_config", nil); errors.Cause(err) != injected {
+		t.Errorf("expected the injected fault, got %v", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("the short-circuited call reached the transport")
+	}
+}
+
+func TestInterceptors_RetriesInsideNext(t *testing.T) {
+	attempts, intercepted := 0, 0
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection reset")
+		}
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport), fastRetry(5), WithInterceptors(
+		func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error) {
+			intercepted++
+			return next(ctx, req)
+		}))
+
+	if _, err := api.Call("condenser_api", "get_config", nil); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 || intercepted != 1 {
+		t.Errorf("expected 3 attempts in 1 intercepted call, got %d in %d", attempts, intercepted)
+	}
+}
+
+func TestInterceptors_HeadersAndSignedCall(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+
+	var methods []string
+	api := NewAPI(node.URL, WithInterceptors(
+		func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error) {
+			methods = append(methods, req.Method)
+			return next(ContextWithHeader(ctx, "X-Request-Source", "conveyor"), req)
+		}))
+
+	// The node does not serve conveyor, but the call must reach it.
+	_, err := api.SignedCall("conveyor.get_feature_flags", []interface{}{"alice"}, "alice", testPrivateKey)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != rpcMethodNotFound {
+		t.Fatalf("expected the node's method-not-found error, got %v", err)
+	}
+	if reqs := node.Requests(); len(reqs) != 1 || reqs[0].Header.Get("X-Request-Source") != "conveyor" {
+		t.Errorf("expected the injected header on the signed call, got %+v", reqs)
+	}
+	if len(methods) != 1 || methods[0] != "conveyor.get_feature_flags" {
+		t.Errorf("expected the signed call to be intercepted, got %v", methods)
+	}
+}
+
+// TestInterceptors_Batch checks every item of a batch is intercepted on its
+// own while the items that get through still go out as one request.
+func TestInterceptors_Batch(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AdvanceBlocks(5)
+
+	var mu sync.Mutex
+	var intercepted []uint
+	injected := errors.New("injected fault")
+	api := NewAPI(node.URL, WithBatchSize(10), WithInterceptors(
+		func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error) {
+			num := req.Params.([]interface{})[0].(uint)
+			mu.Lock()
+			intercepted = append(intercepted, num)
+			mu.Unlock()
+			if num == 3 {
+				return nil, injected
+			}
+			return next(ContextWithHeader(ctx, "X-Trace-Id", "trace-1"), req)
+		}))
+
+	_, err := api.GetBlocks(1, 6)
+	var rangeErr *BlockRangeError
+	if !errors.As(err, &rangeErr) || rangeErr.Block != 3 || !errors.Is(err, injected) {
+		t.Fatalf("expected the injected fault for block 3, got %v", err)
+	}
+	if len(intercepted) != 5 {
+		t.Errorf("expected every block to be intercepted, got %v", intercepted)
+	}
+	if got := node.Posts(); got != 1 {
+		t.Errorf("expected 1 batch request, got %d", got)
+	}
+	reqs := node.Requests()
+	if got := len(reqs); got != 4 {
+		t.Fatalf("expected the 4 blocks let through in the batch, got %d", got)
+	}
+	if got := reqs[0].Header.Get("X-Trace-Id"); got != "trace-1" {
+		t.Errorf("expected the interceptor's header on the batch, got %q", got)
+	}
+}
+
+func TestInterceptors_BatchAnsweredByInterceptor(t *testing.T) {
+	var sent int32
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		atomic.AddInt32(&sent, 1)
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`"node"`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport), WithInterceptors(
+		func(ctx context.Context, req *RPCRequest, next Invoker) (*RPCResponse, error) {
+			if req.Method == "condenser_api.get_config" {
+				return &RPCResponse{ID: req.ID, Result: json.RawMessage(`"stub"`)}, nil
+			}
+			return next(ctx, req)
+		}))
+
+	var config, version string
+	err := api.NewBatch().
+		Add("condenser_api", "get_config", nil, &config).
+		Add("condenser_api", "get_version", nil, &version).
+		Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != "stub" || version != "node" {
+		t.Errorf("unexpected results %q, %q", config, version)
+	}
+	if got := atomic.LoadInt32(&sent); got != 1 {
+		t.Errorf("expected only the second item to reach the transport, got %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import "context"
+
+// LogLevel is the severity of a log event. The values match those of
+// log/slog's levels.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Logger receives the SDK's log events: retries, node failover and, at debug
+// level, transaction signing diagnostics. keyvals alternate keys and values,
+// as with log/slog:
+//
+//	logger.Log(ctx, api.LevelWarn, "retrying request", "method", method, "attempt", 2)
+//
+// Enabled lets callers skip building expensive events nobody will see.
+// Implementations must be safe for concurrent use. NewSlogLogger adapts a
+// *slog.Logger (Go 1.21 and later).
+type Logger interface {
+	Enabled(ctx context.Context, level LogLevel) bool
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NopLogger discards every event. It is the default logger.
+type NopLogger struct{}
+
+func (NopLogger) Enabled(context.Context, LogLevel) bool { return false }
+
+func (NopLogger) Log(context.Context, LogLevel, string, ...interface{}) {}
+
+// WithLogger sends the API's log events to l; nil restores the default
+// NopLogger. A Broadcast built with this option logs to l too.
+func WithLogger(l Logger) Option {
+	return func(a *API) {
+		if l == nil {
+			l = NopLogger{}
+		}
+		a.logger = l
+	}
+}
+
+// Logger returns the logger the API was configured with.
+func (a *API) Logger() Logger {
+	return a.logger
+}
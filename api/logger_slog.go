@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package api
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger adapts a *slog.Logger to Logger.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that writes to l, or to slog.Default() if l
+// is nil:
+//
+//	a := api.NewAPI(url, api.WithLogger(api.NewSlogLogger(slog.Default())))
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l: l}
+}
+
+func (s slogLogger) Enabled(ctx context.Context, level LogLevel) bool {
+	return s.l.Enabled(ctx, slog.Level(level))
+}
+
+func (s slogLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	s.l.Log(ctx, slog.Level(level), msg, keyvals...)
+}
@@ -0,0 +1,24 @@
+//go:build go1.21
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	if logger.Enabled(context.Background(), LevelDebug) {
+		t.Error("debug must be disabled at info level")
+	}
+	logger.Log(context.Background(), LevelWarn, "retrying request", "method", "condenser_api.get_config", "attempt", 1)
+	if got := out.String(); !strings.Contains(got, `level=WARN msg="retrying request" method=condenser_api.get_config attempt=1`) {
+		t.Errorf("unexpected output %q", got)
+	}
+}
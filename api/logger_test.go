@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// recordingLogger keeps every event at or above min.
+type recordingLogger struct {
+	min    LogLevel
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *recordingLogger) Enabled(ctx context.Context, level LogLevel) bool {
+	return level >= l.min
+}
+
+func (l *recordingLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, fmt.Sprint(level, " ", msg, " ", keyvals))
+}
+
+func TestWithLogger_Retries(t *testing.T) {
+	attempts := 0
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection reset")
+		}
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+	})
+	logger := &recordingLogger{min: LevelWarn}
+	api := NewAPI("http://node", WithTransport(transport), fastRetry(5), WithLogger(logger))
+
+	if _, err := api.Call("condenser_api", "get_config", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.events) != 2 {
+		t.Fatalf("expected 2 retry events, got %v", logger.events)
+	}
+	want := "WARN retrying request [method condenser_api.get_config attempt 1 backoff 1ms error connection reset]"
+	if logger.events[0] != want {
+		t.Errorf("got %q, want %q", logger.events[0], want)
+	}
+}
+
+func TestWithLogger_Default(t *testing.T) {
+	if _, ok := NewAPI("http://node").Logger().(NopLogger); !ok {
+		t.Error("expected NopLogger by default")
+	}
+	if _, ok := NewAPI("http://node", WithLogger(nil)).Logger().(NopLogger); !ok {
+		t.Error("expected WithLogger(nil) to restore NopLogger")
+	}
+}
+
+func TestPoolLogger(t *testing.T) {
+	logger := &recordingLogger{min: LevelWarn}
+	failing := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return nil, errors.New("connection refused")
+	})
+	pool, err := NewPool([]string{"http://a", "http://b"},
+		WithPoolNodeTransport(func(string) Transport { return failing }),
+		WithPoolMaxFailures(1, 0), WithPoolLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Send(context.Background(), &RPCRequest{Method: "condenser_api.get_config"})
+	// A failure and a demotion for each node.
+	if len(logger.events) != 4 {
+		t.Errorf("expected 4 events, got %v", logger.events)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Metrics receives measurements of the requests an API makes, e.g. to export
+// them to a monitoring system. Implementations must be safe for concurrent
+// use. PrometheusMetrics is a ready-made implementation.
+type Metrics interface {
+	// ObserveCall records a call of method that took d, retries included.
+	// class is ErrorClass of the call's error: "" if it succeeded. Each call
+	// of a batch is recorded on its own, with the duration of the batch.
+	ObserveCall(method string, d time.Duration, class string)
+	// ObserveRetry records a retry of a call of method. A batch retried as
+	// a whole is recorded as method "batch".
+	ObserveRetry(method string)
+	// ObserveBroadcast records a transaction broadcast in mode "sync" or
+	// "async". class is "" if the node accepted it.
+	ObserveBroadcast(mode string, class string)
+}
+
+// NopMetrics discards every measurement. It is the default.
+type NopMetrics struct{}
+
+func (NopMetrics) ObserveCall(string, time.Duration, string) {}
+
+func (NopMetrics) ObserveRetry(string) {}
+
+func (NopMetrics) ObserveBroadcast(string, string) {}
+
+// WithMetrics sends the API's measurements to m; nil restores the default
+// NopMetrics. A Broadcast built with this option reports its broadcasts to m
+// too.
+func WithMetrics(m Metrics) Option {
+	return func(a *API) {
+		if m == nil {
+			m = NopMetrics{}
+		}
+		a.metrics = m
+	}
+}
+
+// Metrics returns the metrics collector the API was configured with.
+func (a *API) Metrics() Metrics {
+	return a.metrics
+}
+
+// observeCall reports a call of method started at start that ended with
+// *err. It is meant to be deferred.
+func (a *API) observeCall(method string, start time.Time, err *error) {
+	a.metrics.ObserveCall(method, time.Since(start), ErrorClass(*err))
+}
+
+// rpcErrorKinds names the RPC error sentinels for ErrorClass.
+var rpcErrorKinds = map[error]string{
+	ErrMissingAuthority:     "missing_authority",
+	ErrDuplicateTransaction: "duplicate_transaction",
+	ErrExpiredTransaction:   "expired_transaction",
+	ErrInsufficientRC:       "insufficient_rc",
+	ErrUnknownAccount:       "unknown_account",
+}
+
+// ErrorClass sorts err into a small, fixed set of classes fit for metric
+// labels: "" for nil, "canceled", "deadline", "http_status", "network" or
+// "transport" for failures to get an answer, and for node errors "rpc" or,
+// when the error was recognised, the name of its sentinel such as
+// "duplicate_transaction".
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "deadline"
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		if kind, ok := rpcErrorKinds[rpcErr.Kind()]; ok {
+			return kind
+		}
+		return "rpc"
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return "http_status"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+	return "transport"
+}
+
+// callName returns the dotted method name of a request in either wire form,
+// for logs and metrics.
+func callName(method string, params interface{}) string {
+	if method != "call" {
+		return method
+	}
+	if call, ok := params.([]interface{}); ok && len(call) == 3 {
+		apiName, ok1 := call[0].(string)
+		name, ok2 := call[1].(string)
+		if ok1 && ok2 {
+			return apiName + "." + name
+		}
+	}
+	return method
+}
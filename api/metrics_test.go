@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestErrorClass(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{errors.Wrap(context.Canceled, "failed"), "canceled"},
+		{context.DeadlineExceeded, "deadline"},
+		{&HTTPStatusError{StatusCode: 502}, "http_status"},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, "network"},
+		{errors.New("unexpected EOF"), "transport"},
+		{newRPCError("m", json.RawMessage(`{"code":-32000,"message":"bad cast"}`)), "rpc"},
+		{errors.Wrap(newRPCError("m", json.RawMessage(`{"code":-32000,"message":"Duplicate transaction check failed"}`)), "x"), "duplicate_transaction"},
+	} {
+		if got := ErrorClass(tc.err); got != tc.want {
+			t.Errorf("ErrorClass(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	attempts := 0
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		if callName(req.Method, req.Params) == "condenser_api.get_accounts" {
+			return &RPCResponse{ID: req.ID, Error: json.RawMessage(`{"code":-32000,"message":"unknown account"}`)}, nil
+		}
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+	})
+	m := NewPrometheusMetrics(0.5, 1)
+	api := NewAPI("http://node", WithTransport(transport), fastRetry(3), WithMetrics(m), WithLegacyCallForm())
+
+	api.Call("condenser_api", "get_config", nil)
+	api.GetAccounts([]string{"ghost"})
+	m.ObserveBroadcast("sync", "")
+	m.ObserveBroadcast("sync", "expired_transaction")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	for _, line := range []string{
+		"# TYPE steem_rpc_calls_total counter",
+		`steem_rpc_calls_total{method="condenser_api.get_config"} 1`,
+		`steem_rpc_calls_total{method="condenser_api.get_accounts"} 1`,
+		`steem_rpc_errors_total{method="condenser_api.get_accounts",class="unknown_account"} 1`,
+		"# TYPE steem_rpc_call_duration_seconds histogram",
+		`steem_rpc_call_duration_seconds_bucket{method="condenser_api.get_config",le="0.5"} 1`,
+		`steem_rpc_call_duration_seconds_bucket{method="condenser_api.get_config",le="+Inf"} 1`,
+		`steem_rpc_call_duration_seconds_count{method="condenser_api.get_config"} 1`,
+		`steem_rpc_retries_total{method="condenser_api.get_config"} 1`,
+		`steem_broadcasts_total{mode="sync",result="expired_transaction"} 1`,
+		`steem_broadcasts_total{mode="sync",result="success"} 1`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+}
+
+func TestPrometheusMetrics_Batch(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		if firstParam(req) == 2 {
+			return &RPCResponse{ID: req.ID, Error: json.RawMessage(`{"code":-32000,"message":"unknown account"}`)}, nil
+		}
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+	})
+	m := NewPrometheusMetrics(1)
+	api := NewAPI("http://node", WithTransport(transport), WithMetrics(m), WithBatchSize(10))
+
+	api.NewBatch().
+		Add("condenser_api", "get_follow_count", []interface{}{1}, nil).
+		Add("condenser_api", "get_follow_count", []interface{}{2}, nil).
+		Do()
+	api.GetBlocks(1, 4)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, line := range []string{
+		`steem_rpc_calls_total{method="condenser_api.get_follow_count"} 2`,
+		`steem_rpc_errors_total{method="condenser_api.get_follow_count",class="unknown_account"} 1`,
+		`steem_rpc_calls_total{method="condenser_api.get_block"} 3`,
+		`steem_rpc_call_duration_seconds_count{method="condenser_api.get_block"} 3`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	if got := labelValue("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+func TestCallNamed_SendsObjectParams(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddOrder("alice", "2.000 STEEM", "1.000 SBD")
+	api := NewAPI(node.URL)
+
+	var result struct {
+		Asks []json.RawMessage `json:"asks"`
+	}
+	if err := api.CallNamed("database_api", "get_order_book", map[string]interface{}{"limit": 10}, &result); err != nil {
+		t.Fatalf("CallNamed failed: %v", err)
+	}
+	if len(result.Asks) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	captured := node.Requests()
+	if len(captured) != 1 || captured[0].Method != "database_api.get_order_book" {
+		t.Fatalf("unexpected requests: %+v", captured)
+	}
+	if got := string(captured[0].Params); got != `{"limit":10}` {
+		t.Errorf("expected object params, got %s", got)
+	}
+}
+
+func TestCallNamed_NilParamsSendsEmptyObject(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
+
+	if err := api.CallNamed("database_api", "get_feed_history", nil, nil); err != nil {
+		t.Fatalf("CallNamed failed: %v", err)
+	}
+	if got := string(node.Requests()[0].Params); got != `{}` {
+		t.Errorf("expected {} params, got %s", got)
+	}
+}
+
+func TestLegacyCallForm(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddAccount("alice", nil)
+	api := NewAPI(node.URL, WithLegacyCallForm())
+
+	if _, err := api.LookupAccounts("a", 1); err != nil {
+		t.Fatalf("LookupAccounts failed: %v", err)
+	}
+	var out json.RawMessage
+	if err := api.CallNamed("database_api", "find_accounts", map[string]interface{}{"accounts": []string{"alice"}}, &out); err != nil {
+		t.Fatalf("CallNamed failed: %v", err)
+	}
+
+	want := []struct{ method, params string }{
+		{"condenser_api.lookup_accounts", `["a",1]`},
+		{"database_api.find_accounts", `{"accounts":["alice"]}`},
+	}
+	captured := node.Requests()
+	if len(captured) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(captured))
+	}
+	for i, w := range want {
+		if c := captured[i]; !c.Legacy || c.Method != w.method || string(c.Params) != w.params {
+			t.Errorf("request %d: got %s %s (legacy %v), want call %s %s", i, c.Method, c.Params, c.Legacy, w.method, w.params)
+		}
+	}
+}
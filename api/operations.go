@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/steemit/steemutil/protocol"
+)
+
+// Operation types steemutil has no payload struct for. All of them are
+// virtual operations, produced by the chain rather than signed by users.
+const (
+	TypeAuthorReward            protocol.OpType = "author_reward"
+	TypeCurationReward          protocol.OpType = "curation_reward"
+	TypeShutdownWitness         protocol.OpType = "shutdown_witness"
+	TypeHardfork                protocol.OpType = "hardfork"
+	TypeCommentPayoutUpdate     protocol.OpType = "comment_payout_update"
+	TypeReturnVestingDelegation protocol.OpType = "return_vesting_delegation"
+	TypeCommentBenefactorReward protocol.OpType = "comment_benefactor_reward"
+	TypeProducerReward          protocol.OpType = "producer_reward"
+	TypeClearNullAccountBalance protocol.OpType = "clear_null_account_balance"
+	TypeProposalPay             protocol.OpType = "proposal_pay"
+	TypeSPSFund                 protocol.OpType = "sps_fund"
+)
+
+// AuthorRewardOperation is the author_reward virtual operation.
+type AuthorRewardOperation struct {
+	Author        string `json:"author"`
+	Permlink      string `json:"permlink"`
+	SBDPayout     string `json:"sbd_payout"`
+	SteemPayout   string `json:"steem_payout"`
+	VestingPayout string `json:"vesting_payout"`
+}
+
+func (op *AuthorRewardOperation) Type() protocol.OpType {
+	return TypeAuthorReward
+}
+
+func (op *AuthorRewardOperation) Data() any {
+	return op
+}
+
+// CurationRewardOperation is the curation_reward virtual operation.
+type CurationRewardOperation struct {
+	Curator         string `json:"curator"`
+	Reward          string `json:"reward"`
+	CommentAuthor   string `json:"comment_author"`
+	CommentPermlink string `json:"comment_permlink"`
+}
+
+func (op *CurationRewardOperation) Type() protocol.OpType {
+	return TypeCurationReward
+}
+
+func (op *CurationRewardOperation) Data() any {
+	return op
+}
+
+// ShutdownWitnessOperation is the shutdown_witness virtual operation.
+type ShutdownWitnessOperation struct {
+	Owner string `json:"owner"`
+}
+
+func (op *ShutdownWitnessOperation) Type() protocol.OpType {
+	return TypeShutdownWitness
+}
+
+func (op *ShutdownWitnessOperation) Data() any {
+	return op
+}
+
+// HardforkOperation is the hardfork virtual operation.
+type HardforkOperation struct {
+	HardforkID uint32 `json:"hardfork_id"`
+}
+
+func (op *HardforkOperation) Type() protocol.OpType {
+	return TypeHardfork
+}
+
+func (op *HardforkOperation) Data() any {
+	return op
+}
+
+// CommentPayoutUpdateOperation is the comment_payout_update virtual
+// operation.
+type CommentPayoutUpdateOperation struct {
+	Author   string `json:"author"`
+	Permlink string `json:"permlink"`
+}
+
+func (op *CommentPayoutUpdateOperation) Type() protocol.OpType {
+	return TypeCommentPayoutUpdate
+}
+
+func (op *CommentPayoutUpdateOperation) Data() any {
+	return op
+}
+
+// ReturnVestingDelegationOperation is the return_vesting_delegation virtual
+// operation.
+type ReturnVestingDelegationOperation struct {
+	Account       string `json:"account"`
+	VestingShares string `json:"vesting_shares"`
+}
+
+func (op *ReturnVestingDelegationOperation) Type() protocol.OpType {
+	return TypeReturnVestingDelegation
+}
+
+func (op *ReturnVestingDelegationOperation) Data() any {
+	return op
+}
+
+// CommentBenefactorRewardOperation is the comment_benefactor_reward virtual
+// operation.
+type CommentBenefactorRewardOperation struct {
+	Benefactor    string `json:"benefactor"`
+	Author        string `json:"author"`
+	Permlink      string `json:"permlink"`
+	SBDPayout     string `json:"sbd_payout"`
+	SteemPayout   string `json:"steem_payout"`
+	VestingPayout string `json:"vesting_payout"`
+}
+
+func (op *CommentBenefactorRewardOperation) Type() protocol.OpType {
+	return TypeCommentBenefactorReward
+}
+
+func (op *CommentBenefactorRewardOperation) Data() any {
+	return op
+}
+
+// ProducerRewardOperation is the producer_reward virtual operation.
+type ProducerRewardOperation struct {
+	Producer      string `json:"producer"`
+	VestingShares string `json:"vesting_shares"`
+}
+
+func (op *ProducerRewardOperation) Type() protocol.OpType {
+	return TypeProducerReward
+}
+
+func (op *ProducerRewardOperation) Data() any {
+	return op
+}
+
+// ClearNullAccountBalanceOperation is the clear_null_account_balance virtual
+// operation.
+type ClearNullAccountBalanceOperation struct {
+	TotalCleared []string `json:"total_cleared"`
+}
+
+func (op *ClearNullAccountBalanceOperation) Type() protocol.OpType {
+	return TypeClearNullAccountBalance
+}
+
+func (op *ClearNullAccountBalanceOperation) Data() any {
+	return op
+}
+
+// ProposalPayOperation is the proposal_pay virtual operation.
+type ProposalPayOperation struct {
+	Receiver string `json:"receiver"`
+	Payment  string `json:"payment"`
+	TrxID    string `json:"trx_id"`
+	OpInTrx  uint16 `json:"op_in_trx"`
+}
+
+func (op *ProposalPayOperation) Type() protocol.OpType {
+	return TypeProposalPay
+}
+
+func (op *ProposalPayOperation) Data() any {
+	return op
+}
+
+// SPSFundOperation is the sps_fund virtual operation.
+type SPSFundOperation struct {
+	AdditionalFunds string `json:"additional_funds"`
+}
+
+func (op *SPSFundOperation) Type() protocol.OpType {
+	return TypeSPSFund
+}
+
+func (op *SPSFundOperation) Data() any {
+	return op
+}
+
+// RawOperation is an operation whose payload DecodeOperation could not map
+// to a struct. Like steemutil's unknown operations, Data returns the payload
+// as *json.RawMessage.
+type RawOperation struct {
+	OpType  protocol.OpType
+	Payload json.RawMessage
+}
+
+func (op *RawOperation) Type() protocol.OpType {
+	return op.OpType
+}
+
+func (op *RawOperation) Data() any {
+	return &op.Payload
+}
+
+var (
+	operationsMu sync.RWMutex
+	// operationTypes holds the payload constructors DecodeOperation tries
+	// before falling back to steemutil's own registry.
+	operationTypes = map[protocol.OpType]func() protocol.Operation{
+		TypeAuthorReward:            func() protocol.Operation { return &AuthorRewardOperation{} },
+		TypeCurationReward:          func() protocol.Operation { return &CurationRewardOperation{} },
+		TypeShutdownWitness:         func() protocol.Operation { return &ShutdownWitnessOperation{} },
+		TypeHardfork:                func() protocol.Operation { return &HardforkOperation{} },
+		TypeCommentPayoutUpdate:     func() protocol.Operation { return &CommentPayoutUpdateOperation{} },
+		TypeReturnVestingDelegation: func() protocol.Operation { return &ReturnVestingDelegationOperation{} },
+		TypeCommentBenefactorReward: func() protocol.Operation { return &CommentBenefactorRewardOperation{} },
+		TypeProducerReward:          func() protocol.Operation { return &ProducerRewardOperation{} },
+		TypeClearNullAccountBalance: func() protocol.Operation { return &ClearNullAccountBalanceOperation{} },
+		TypeProposalPay:             func() protocol.Operation { return &ProposalPayOperation{} },
+		TypeSPSFund:                 func() protocol.Operation { return &SPSFundOperation{} },
+	}
+)
+
+// RegisterOperation makes DecodeOperation decode payloads of opType with the
+// operation newOp returns, e.g. for custom or future operation types.
+// Registering a type steemutil already knows overrides its struct.
+func RegisterOperation(opType protocol.OpType, newOp func() protocol.Operation) {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	operationTypes[opType] = newOp
+}
+
+// DecodeOperation decodes the payload of an operation of type opType into
+// its protocol struct: one registered with RegisterOperation, one defined in
+// this package, or steemutil's. Unknown types, and payloads that do not fit
+// their struct, are returned as *RawOperation so that nothing is lost.
+func DecodeOperation(opType protocol.OpType, payload json.RawMessage) protocol.Operation {
+	operationsMu.RLock()
+	newOp, ok := operationTypes[opType]
+	operationsMu.RUnlock()
+	if ok {
+		op := newOp()
+		if err := json.Unmarshal(payload, op); err == nil {
+			return op
+		}
+		return &RawOperation{OpType: opType, Payload: payload}
+	}
+
+	// steemutil keeps its registry private; decode through its operation
+	// list, which maps [type, payload] tuples to its structs.
+	tuple, err := json.Marshal([]interface{}{[]interface{}{opType, payload}})
+	if err == nil {
+		var ops protocol.Operations
+		if json.Unmarshal(tuple, &ops) == nil && len(ops) == 1 {
+			if _, unknown := ops[0].(*protocol.UnknownOperation); !unknown {
+				return ops[0]
+			}
+		}
+	}
+	return &RawOperation{OpType: opType, Payload: payload}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/steemit/steemutil/protocol"
+)
+
+func decodeHistoryEntry(t *testing.T, raw string) *AccountHistoryEntry {
+	t.Helper()
+	var e AccountHistoryEntry
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		t.Fatalf("failed to decode %s: %v", raw, err)
+	}
+	return &e
+}
+
+func TestAccountHistoryEntry_TypedPayload(t *testing.T) {
+	e := decodeHistoryEntry(t, `[7, {"trx_id":"a1b2","block":1000,"trx_in_block":3,"op_in_trx":1,"virtual_op":0,
+		"op":["transfer",{"from":"alice","to":"bob","amount":"1.000 STEEM","memo":"hi"}],"timestamp":"2026-01-01T00:00:00"}]`)
+	transfer, ok := e.Op.Data.(*protocol.TransferOperation)
+	if !ok {
+		t.Fatalf("expected *protocol.TransferOperation, got %T", e.Op.Data)
+	}
+	if transfer.From != "alice" || transfer.To != "bob" || transfer.Memo != "hi" {
+		t.Errorf("unexpected transfer %+v", transfer)
+	}
+	if e.TransactionID != "a1b2" || e.BlockNumber != 1000 || e.TransactionInBlock != 3 || e.OperationInTransaction != 1 {
+		t.Errorf("chain position not kept: %+v", e)
+	}
+	if e.Op.Payload["memo"] != "hi" {
+		t.Errorf("expected the payload map to be kept, got %v", e.Op.Payload)
+	}
+}
+
+func TestAccountHistoryEntry_VirtualOperations(t *testing.T) {
+	cases := []struct {
+		op   string
+		want interface{}
+	}{
+		{`["author_reward",{"author":"alice","permlink":"p","sbd_payout":"0.000 SBD","steem_payout":"0.000 STEEM","vesting_payout":"1.000000 VESTS"}]`,
+			&AuthorRewardOperation{Author: "alice", Permlink: "p", SBDPayout: "0.000 SBD", SteemPayout: "0.000 STEEM", VestingPayout: "1.000000 VESTS"}},
+		{`["curation_reward",{"curator":"carol","reward":"2.000000 VESTS","comment_author":"alice","comment_permlink":"p"}]`,
+			&CurationRewardOperation{Curator: "carol", Reward: "2.000000 VESTS", CommentAuthor: "alice", CommentPermlink: "p"}},
+		{`["producer_reward",{"producer":"witness1","vesting_shares":"3.000000 VESTS"}]`,
+			&ProducerRewardOperation{Producer: "witness1", VestingShares: "3.000000 VESTS"}},
+		{`["fill_order",{"current_owner":"alice","current_orderid":1,"current_pays":"1.000 SBD","open_owner":"bob","open_orderid":2,"open_pays":"3.000 STEEM"}]`,
+			&protocol.FillOrderOperation{CurrentOwner: "alice", CurrentOrderID: 1, CurrentPays: "1.000 SBD", OpenOwner: "bob", OpenOrderID: 2, OpenPays: "3.000 STEEM"}},
+	}
+	for _, tc := range cases {
+		e := decodeHistoryEntry(t, `[1, {"virtual_op":5,"op":`+tc.op+`,"timestamp":"2026-01-01T00:00:00"}]`)
+		got, _ := json.Marshal(e.Op.Data)
+		want, _ := json.Marshal(tc.want)
+		if string(got) != string(want) {
+			t.Errorf("%s: got %T %s, want %s", e.Op.Type, e.Op.Data, got, want)
+		}
+		if e.VirtualOperation != 5 {
+			t.Errorf("%s: expected virtual_op 5, got %d", e.Op.Type, e.VirtualOperation)
+		}
+	}
+}
+
+func TestAccountHistoryEntry_UnknownKeepsRaw(t *testing.T) {
+	e := decodeHistoryEntry(t, `[1, {"op":["teleport",{"to":"mars"}],"timestamp":"2026-01-01T00:00:00"}]`)
+	raw, ok := e.Op.Data.(*RawOperation)
+	if !ok {
+		t.Fatalf("expected *RawOperation, got %T", e.Op.Data)
+	}
+	if raw.Type() != "teleport" || string(raw.Payload) != `{"to":"mars"}` {
+		t.Errorf("unexpected raw operation %s %s", raw.Type(), raw.Payload)
+	}
+	if data, ok := raw.Data().(*json.RawMessage); !ok || string(*data) != `{"to":"mars"}` {
+		t.Errorf("expected Data to return the raw payload, got %v", raw.Data())
+	}
+	if string(e.Op.Raw) != `{"to":"mars"}` {
+		t.Errorf("expected Raw to hold the payload, got %s", e.Op.Raw)
+	}
+}
+
+type teleportOperation struct {
+	To string `json:"to"`
+}
+
+func (op *teleportOperation) Type() protocol.OpType { return "teleport" }
+func (op *teleportOperation) Data() any             { return op }
+
+func TestRegisterOperation(t *testing.T) {
+	RegisterOperation("teleport", func() protocol.Operation { return &teleportOperation{} })
+	defer func() {
+		operationsMu.Lock()
+		delete(operationTypes, "teleport")
+		operationsMu.Unlock()
+	}()
+
+	op := DecodeOperation("teleport", json.RawMessage(`{"to":"mars"}`))
+	if tp, ok := op.(*teleportOperation); !ok || tp.To != "mars" {
+		t.Errorf("expected the registered type, got %T %+v", op, op)
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/steemit/steemutil/protocol"
+)
+
+// OperationFilter selects operations for StreamOperations. The zero value
+// matches every operation.
+type OperationFilter struct {
+	// Types lists operation type names to keep, e.g. "transfer" or
+	// "producer_reward". Empty keeps every type.
+	Types []string
+	// Accounts keeps only operations that involve at least one of these
+	// accounts (see OperationAccounts). Empty keeps operations regardless of
+	// the accounts involved.
+	Accounts []string
+}
+
+// Match reports whether op passes the filter.
+func (f OperationFilter) Match(op *protocol.OperationObject) bool {
+	if op == nil || op.Operation == nil {
+		return false
+	}
+	if len(f.Types) > 0 && !containsString(f.Types, string(op.Operation.Type())) {
+		return false
+	}
+	if len(f.Accounts) == 0 {
+		return true
+	}
+	for _, account := range OperationAccounts(op.Operation) {
+		if containsString(f.Accounts, account) {
+			return true
+		}
+	}
+	return false
+}
+
+// operationAccountFields are the operation fields, across regular and
+// virtual operations, that name an account.
+var operationAccountFields = []string{
+	"account", "account_to_recover", "account_to_reset", "agent", "author",
+	"benefactor", "comment_author", "creator", "curator", "current_owner",
+	"delegatee", "delegator", "from", "from_account", "new_account_name",
+	"new_recovery_account", "open_owner", "owner", "parent_author", "payer",
+	"producer", "proxy", "publisher", "receiver", "recovery_account",
+	"required_auths", "required_posting_auths", "reset_account", "to",
+	"to_account", "voter", "who", "witness",
+}
+
+// OperationAccounts returns the accounts an operation involves: senders,
+// receivers, authors, voters, reward recipients and signing authorities. It
+// works on the JSON form of the operation, so operation types unknown to
+// steemutil are covered as well.
+func OperationAccounts(op protocol.Operation) []string {
+	raw, err := json.Marshal(op.Data())
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	var accounts []string
+	add := func(name string) {
+		if name != "" && !containsString(accounts, name) {
+			accounts = append(accounts, name)
+		}
+	}
+	for _, field := range operationAccountFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		var name string
+		if json.Unmarshal(value, &name) == nil {
+			add(name)
+			continue
+		}
+		var names []string
+		if json.Unmarshal(value, &names) == nil {
+			for _, name := range names {
+				add(name)
+			}
+		}
+	}
+	return accounts
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WithStreamMode sets which end of the chain StreamOperations follows.
+// Defaults to StreamIrreversible. StreamBlocks takes its mode as an argument
+// and ignores this option.
+func WithStreamMode(mode StreamMode) StreamOption {
+	return func(c *streamConfig) {
+		c.mode = mode
+	}
+}
+
+// OperationStream is a running operation stream started by StreamOperations.
+// Receive operations from C; once C is closed, Err reports why the stream
+// stopped.
+type OperationStream struct {
+	// C delivers matching operations, regular and virtual, in chain order.
+	C <-chan *protocol.OperationObject
+
+	streamState
+	acks *acker
+}
+
+// Ack acknowledges that op, and every operation delivered before it, has
+// been processed. With WithCheckpointer its position is saved as the resume
+// point; otherwise Ack does nothing.
+func (s *OperationStream) Ack(op *protocol.OperationObject) error {
+	return s.acks.ack(op)
+}
+
+// StreamOperations follows the chain from block from (0 = the current block)
+// and delivers every operation, including virtual operations, that matches
+// filter. It follows the last irreversible block unless WithStreamMode says
+// otherwise, and otherwise behaves like StreamBlocks.
+//
+// To resume after a restart, use WithCheckpointer and Ack each operation
+// once handled: the stream then restarts right after the last acknowledged
+// operation. Without a checkpointer, start again from the block number of
+// the last operation handled; operations of that block are delivered again.
+//
+//	stream := a.StreamOperations(ctx, lastBlock, api.OperationFilter{
+//		Types:    []string{"transfer"},
+//		Accounts: []string{"exchange"},
+//	})
+//	for op := range stream.C {
+//		handle(op)
+//	}
+func (a *API) StreamOperations(ctx context.Context, from uint, filter OperationFilter, opts ...StreamOption) *OperationStream {
+	cfg := defaultStreamConfig()
+	cfg.mode = StreamIrreversible
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ch := make(chan *protocol.OperationObject, cfg.buffer)
+	s := &OperationStream{C: ch, streamState: newStreamState(), acks: &acker{cp: cfg.checkpointer}}
+	go func() {
+		from, skip, err := s.acks.resume(ctx, from)
+		if err != nil {
+			close(ch)
+			s.finish(err)
+			return
+		}
+		resumeBlock := from
+		err = a.follow(ctx, from, cfg.mode, cfg, func(next, end uint) (uint, error) {
+			opsMap, err := a.GetOpsInBlocksContext(ctx, next, end, false)
+			if err != nil {
+				return 0, err
+			}
+			var delivered uint
+			for n := next; n < end; n++ {
+				for i, op := range opsMap[n] {
+					if n == resumeBlock && i < skip {
+						continue // acknowledged before the restart
+					}
+					if !filter.Match(op) {
+						continue
+					}
+					s.acks.track(op, Checkpoint{BlockNum: n, OpIndex: i})
+					select {
+					case ch <- op:
+					case <-ctx.Done():
+						return delivered, ctx.Err()
+					}
+				}
+				delivered++
+			}
+			return delivered, s.acks.idle(end - 1)
+		})
+		close(ch)
+		s.finish(err)
+	}()
+	return s
+}
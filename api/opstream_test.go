@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steemit/steemutil/protocol"
+)
+
+func collectOps(t *testing.T, stream *OperationStream, n int) []*protocol.OperationObject {
+	t.Helper()
+	var ops []*protocol.OperationObject
+	timeout := time.After(5 * time.Second)
+	for len(ops) < n {
+		select {
+		case op, ok := <-stream.C:
+			if !ok {
+				t.Fatalf("stream stopped after %d ops: %v", len(ops), stream.Err())
+			}
+			ops = append(ops, op)
+		case <-timeout:
+			t.Fatalf("timed out after %d ops", len(ops))
+		}
+	}
+	return ops
+}
+
+func TestStreamOperations_Filters(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter OperationFilter
+		types  []string // expected op types of one block, in order
+	}{
+		{"all", OperationFilter{}, []string{"transfer", "vote", "future_operation", "producer_reward"}},
+		{"by type", OperationFilter{Types: []string{"vote", "producer_reward"}}, []string{"vote", "producer_reward"}},
+		{"receiver", OperationFilter{Accounts: []string{"bob"}}, []string{"transfer"}},
+		{"virtual op account", OperationFilter{Accounts: []string{"witness1", "dave"}}, []string{"vote", "producer_reward"}},
+		{"unknown op account", OperationFilter{Accounts: []string{"erin"}}, []string{"future_operation"}},
+		{"type and account", OperationFilter{Types: []string{"transfer"}, Accounts: []string{"carol"}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := newStandInChain(t, 12, 0)
+			api := NewAPI("http://node", WithTransport(chain.transport()))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream := api.StreamOperations(ctx, 10, tc.filter, WithStreamMode(StreamHead), WithStreamInterval(time.Millisecond))
+			if tc.types == nil {
+				select {
+				case op := <-stream.C:
+					t.Fatalf("expected no operations, got %+v", op)
+				case <-time.After(30 * time.Millisecond):
+				}
+				return
+			}
+			ops := collectOps(t, stream, 3*len(tc.types))
+			for i, op := range ops {
+				wantBlock := uint32(10 + i/len(tc.types))
+				wantType := tc.types[i%len(tc.types)]
+				if op.BlockNumber != wantBlock || string(op.Operation.Type()) != wantType {
+					t.Errorf("op %d: got %s in block %d, want %s in block %d", i, op.Operation.Type(), op.BlockNumber, wantType, wantBlock)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamOperations_DefaultsToIrreversible(t *testing.T) {
+	chain := newStandInChain(t, 30, 20)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := api.StreamOperations(ctx, 0, OperationFilter{Types: []string{"transfer"}}, WithStreamInterval(time.Millisecond))
+	ops := collectOps(t, stream, 1)
+	if ops[0].BlockNumber != 10 {
+		t.Errorf("expected to start at the last irreversible block 10, got %d", ops[0].BlockNumber)
+	}
+}
+
+func TestOperationAccounts(t *testing.T) {
+	op := &protocol.CustomJSONOperation{
+		RequiredAuths:        []string{"alice"},
+		RequiredPostingAuths: []string{"bob", "alice"},
+		ID:                   "follow",
+		JSON:                 "{}",
+	}
+	got := OperationAccounts(op)
+	if len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Errorf("unexpected accounts: %v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import "net/http"
+
+// Option configures an API created by NewAPI.
+type Option func(*API)
+
+// WithTransport makes the API send every request (including signed calls)
+// through t instead of the default HTTPTransport. Use it to inject mocks,
+// recorders, proxies or instrumented clients.
+func WithTransport(t Transport) Option {
+	return func(a *API) {
+		a.transport = t
+	}
+}
+
+// WithHTTPClient keeps the default HTTP transport but sends requests with
+// client, e.g. to set a custom timeout, proxy or RoundTripper.
+func WithHTTPClient(client *http.Client) Option {
+	return func(a *API) {
+		a.transport = NewHTTPTransport(a.url, client)
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy (see DefaultRetryPolicy).
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(a *API) {
+		a.retry = p
+	}
+}
+
+// WithMaxRetry keeps the default backoff but retries at most n times; 0
+// disables retrying.
+func WithMaxRetry(n int) Option {
+	return func(a *API) {
+		a.retry.MaxRetries = n
+	}
+}
+
+// WithBatchSize makes GetBlocks and GetOpsInBlocks fetch their range in
+// JSON-RPC batches of n requests (DefaultBatchSize if n <= 0) instead of
+// one request per block. The transport must accept batch requests; the
+// public Steem API gateway does.
+func WithBatchSize(n int) Option {
+	return func(a *API) {
+		if n <= 0 {
+			n = DefaultBatchSize
+		}
+		a.batchSize = n
+	}
+}
+
+// WithConcurrency caps how many requests GetBlocks, GetOpsInBlocks, their
+// Each variants and GetAccountsBulk keep in flight at once (DefaultConcurrency if
+// n <= 0). With WithBatchSize it caps the number of batches in flight.
+func WithConcurrency(n int) Option {
+	return func(a *API) {
+		if n <= 0 {
+			n = DefaultConcurrency
+		}
+		a.concurrency = n
+	}
+}
+
+// WithLegacyCallForm sends every call in steemd's legacy "call" wire form,
+// {"method":"call","params":[api, method, params]}, instead of the dotted
+// "api.method" form. Use it for nodes or proxies that predate appbase
+// method routing. Signed calls are unaffected.
+func WithLegacyCallForm() Option {
+	return func(a *API) {
+		a.legacy = true
+	}
+}
+
+// WithCache puts a Cache configured by opts in front of the transport set up
+// so far, so give it after WithTransport or WithHTTPClient. To reach the
+// cache later, e.g. for SetIrreversible or Stats, create it with NewCache and
+// install it with WithTransport instead.
+func WithCache(opts ...CacheOption) Option {
+	return func(a *API) {
+		a.transport = NewCache(a.transport, opts...)
+	}
+}
@@ -3,16 +3,18 @@ package api
 import (
 	"encoding/json"
 	"testing"
+
+	"github.com/steemit/steemgosdk/steemtest"
 )
 
 // params_shape_test.go asserts the EXACT wire shape each G2 method emits.
 //
 // Background: an earlier audit found that GetOrderBook/GetFeedHistory called
 // database_api with positional-array params, but database_api handlers take
-// named objects — so the calls would fail against a real node. The permissive
-// mockRPCServer (which only routes by method) missed this because it never
-// inspected params. These tests use mockRPCServerCapture to record params and
-// assert each method sends the shape its target API actually accepts:
+// named objects — so the calls would fail against a real node. A permissive
+// mock that only routed by method missed this because it never inspected
+// params. These tests read the params a steemtest.Node recorded and assert
+// each method sends the shape its target API actually accepts:
 //
 //   - condenser_api methods: positional arrays (condenser_api handlers are
 //     vector<variant>, indexed as args[0], args[1], ...)
@@ -21,20 +23,14 @@ import (
 // This guards against regressions where a method is retargeted to database_api
 // (which needs named objects) or params are reordered.
 
-// emptyResult is a canned JSON-RPC result that satisfies every method's
-// unmarshal target without needing per-method fixtures — these tests only care
-// about the REQUEST shape, not the response.
-var emptyResult = map[string]interface{}{}
-
 func TestGetAccounts_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_accounts": []interface{}{},
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetAccounts([]string{"alice", "bob"})
 
+	captured := node.Requests()
 	if len(captured) != 1 {
 		t.Fatalf("expected 1 request, got %d", len(captured))
 	}
@@ -57,15 +53,13 @@ func TestGetAccounts_ParamsShape(t *testing.T) {
 }
 
 func TestGetFollowCount_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_follow_count": emptyResult,
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetFollowCount("alice")
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.get_follow_count" {
 		t.Fatalf("expected method condenser_api.get_follow_count, got %s", c.Method)
 	}
@@ -76,15 +70,13 @@ func TestGetFollowCount_ParamsShape(t *testing.T) {
 }
 
 func TestGetFollowers_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_followers": []interface{}{},
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetFollowers("alice", "", "blog", 100)
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.get_followers" {
 		t.Fatalf("expected method condenser_api.get_followers, got %s", c.Method)
 	}
@@ -109,15 +101,13 @@ func TestGetFollowers_ParamsShape(t *testing.T) {
 }
 
 func TestGetFollowing_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_following": []interface{}{},
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetFollowing("alice", "bob", "blog", 50)
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.get_following" {
 		t.Fatalf("expected method condenser_api.get_following, got %s", c.Method)
 	}
@@ -128,15 +118,13 @@ func TestGetFollowing_ParamsShape(t *testing.T) {
 }
 
 func TestGetAccountHistory_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_account_history": []interface{}{},
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetAccountHistory("alice", int64(-1), 1000)
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.get_account_history" {
 		t.Fatalf("expected method condenser_api.get_account_history, got %s", c.Method)
 	}
@@ -157,15 +145,13 @@ func TestGetAccountHistory_ParamsShape(t *testing.T) {
 }
 
 func TestLookupAccounts_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.lookup_accounts": []string{},
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.LookupAccounts("alic", 10)
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.lookup_accounts" {
 		t.Fatalf("expected method condenser_api.lookup_accounts, got %s", c.Method)
 	}
@@ -187,15 +173,13 @@ func TestLookupAccounts_ParamsShape(t *testing.T) {
 // (named object {"limit":N}) — the latter would fail on a real node because
 // steemutil's RpcSendData.Params ([]any) can only emit arrays.
 func TestGetOrderBook_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_order_book": emptyResult,
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetOrderBook(500)
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.get_order_book" {
 		t.Fatalf("REGRESSION: expected condenser_api.get_order_book, got %s — "+
 			"database_api takes a named object {\"limit\":N} which this SDK cannot emit", c.Method)
@@ -213,15 +197,13 @@ func TestGetOrderBook_ParamsShape(t *testing.T) {
 // It MUST target condenser_api with an empty positional array, NOT database_api
 // (named object) — same reason as GetOrderBook.
 func TestGetFeedHistory_ParamsShape(t *testing.T) {
-	var captured []capturedRequest
-	server := mockRPCServerCapture(t, map[string]interface{}{
-		"condenser_api.get_feed_history": emptyResult,
-	}, &captured)
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
 
 	_, _ = api.GetFeedHistory()
 
-	c := captured[0]
+	c := node.Requests()[0]
 	if c.Method != "condenser_api.get_feed_history" {
 		t.Fatalf("REGRESSION: expected condenser_api.get_feed_history, got %s — "+
 			"database_api takes a named object which this SDK cannot emit", c.Method)
@@ -255,15 +237,19 @@ func TestParamsShape_IsArrayNotObject(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			var captured []capturedRequest
-			server := mockRPCServerCapture(t, map[string]interface{}{tc.method: emptyResult}, &captured)
-			api := NewAPI(server.URL)
+			node := steemtest.NewNode()
+			defer node.Close()
+			api := NewAPI(node.URL)
 
 			_ = tc.call(api)
 
+			captured := node.Requests()
 			if len(captured) != 1 {
 				t.Fatalf("expected 1 request, got %d", len(captured))
 			}
+			if captured[0].Method != tc.method {
+				t.Errorf("expected method %s, got %s", tc.method, captured[0].Method)
+			}
 			// params must be a JSON array (starts with '['), never a bare object ('{').
 			p := string(captured[0].Params)
 			if len(p) == 0 || p[0] != '[' {
@@ -0,0 +1,415 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Pool is a Transport that spreads requests over several Steem nodes.
+//
+// Every request goes to the healthiest node first. If that node fails at the
+// transport level, or answers with an RPC error that points at the node rather
+// than the request (see isNodeTroubleRPCError), the request fails over to the
+// next node. Deterministic RPC errors, such as a failed chain assertion, are
+// returned to the caller as-is because every node would give the same answer.
+// A broadcast never fails over on an RPC error, and only on transport errors
+// that prove the node never received it, for the reason given on RetryPolicy.
+//
+// Health is scored from consecutive failures and an exponentially weighted
+// average latency. A node that fails maxFailures times in a row is demoted
+// for the cooldown period, and a node whose head block lags the best node by
+// more than the lag threshold (see CheckHeads and Monitor) is demoted until it
+// catches up. Demoted nodes are still tried, last, when nothing better is left.
+//
+// Install a Pool with WithTransport, or use NewPoolAPI.
+type Pool struct {
+	nodes []*poolNode
+
+	maxFailures  int
+	cooldown     time.Duration
+	lagThreshold uint32
+	logger       Logger
+
+	mu sync.Mutex // guards the stats in every poolNode
+}
+
+type poolNode struct {
+	url       string
+	transport Transport
+
+	latency             time.Duration // EWMA of successful round trips
+	requests            uint64
+	errors              uint64
+	consecutiveFailures int
+	demotedUntil        time.Time
+	lastError           string
+	headBlock           uint32
+	lag                 uint32
+}
+
+// NodeState is a point-in-time snapshot of one pool node, for dashboards.
+type NodeState struct {
+	URL                 string        `json:"url"`
+	Healthy             bool          `json:"healthy"`
+	Latency             time.Duration `json:"latency"`
+	Requests            uint64        `json:"requests"`
+	Errors              uint64        `json:"errors"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastError           string        `json:"last_error,omitempty"`
+	HeadBlock           uint32        `json:"head_block"`
+	Lag                 uint32        `json:"lag"`
+}
+
+// PoolOption configures a Pool.
+type PoolOption func(*Pool)
+
+// WithPoolMaxFailures demotes a node for cooldown after n consecutive failures.
+func WithPoolMaxFailures(n int, cooldown time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.maxFailures = n
+		p.cooldown = cooldown
+	}
+}
+
+// WithPoolLagThreshold demotes nodes whose head block is more than blocks
+// behind the most advanced node.
+func WithPoolLagThreshold(blocks uint32) PoolOption {
+	return func(p *Pool) {
+		p.lagThreshold = blocks
+	}
+}
+
+// WithPoolLogger logs node failures and demotions to l at warn level.
+func WithPoolLogger(l Logger) PoolOption {
+	return func(p *Pool) {
+		if l == nil {
+			l = NopLogger{}
+		}
+		p.logger = l
+	}
+}
+
+// WithPoolNodeTransport builds each node's Transport with newTransport instead
+// of the scheme-based default (HTTP, or WebSocket for ws:// and wss://).
+func WithPoolNodeTransport(newTransport func(url string) Transport) PoolOption {
+	return func(p *Pool) {
+		for _, n := range p.nodes {
+			n.transport = newTransport(n.url)
+		}
+	}
+}
+
+// NewPool creates a Pool over urls. At least one URL is required.
+func NewPool(urls []string, opts ...PoolOption) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("node pool needs at least one url")
+	}
+	p := &Pool{
+		maxFailures:  3,
+		cooldown:     30 * time.Second,
+		lagThreshold: 20, // one minute of 3-second blocks
+		logger:       NopLogger{},
+	}
+	for _, url := range urls {
+		p.nodes = append(p.nodes, &poolNode{
+			url:       url,
+			transport: defaultTransport(url),
+		})
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// NewPoolAPI creates an API that sends every request through a Pool over
+// urls. The pool is returned too so callers can read its State.
+func NewPoolAPI(urls []string, poolOpts []PoolOption, opts ...Option) (*API, *Pool, error) {
+	pool, err := NewPool(urls, poolOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append([]Option{WithTransport(pool)}, opts...)
+	return NewAPI(urls[0], opts...), pool, nil
+}
+
+// Send tries the nodes in health order until one returns a usable response.
+func (p *Pool) Send(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	var (
+		lastResp *RPCResponse
+		lastErr  error
+	)
+	broadcast := isBroadcastMethod(callName(req.Method, req.Params))
+	for _, node := range p.ranked() {
+		start := time.Now()
+		resp, err := node.transport.Send(ctx, req)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The caller gave up; that says nothing about the node.
+				return nil, ctx.Err()
+			}
+			p.recordFailure(node, err.Error())
+			if broadcast && !notDelivered(err) {
+				return nil, errors.Wrapf(err, "node %s", node.url)
+			}
+			lastResp, lastErr = nil, errors.Wrapf(err, "node %s", node.url)
+			continue
+		}
+		if resp.HasError() && isNodeTroubleRPCError(resp.Error) {
+			p.recordFailure(node, string(resp.Error))
+			if broadcast {
+				// A timed out broadcast may still have been accepted.
+				return resp, nil
+			}
+			lastResp, lastErr = resp, nil
+			continue
+		}
+		p.recordSuccess(node, time.Since(start))
+		return resp, nil
+	}
+	if lastResp != nil {
+		return lastResp, nil
+	}
+	return nil, errors.Wrap(lastErr, "all nodes failed")
+}
+
+// SendBatch sends the whole batch to one node, failing over to the next node
+// on transport errors. A batch holding a broadcast fails over like a single
+// broadcast. Per-item RPC errors are left to the caller.
+func (p *Pool) SendBatch(ctx context.Context, reqs []*RPCRequest) ([]*RPCResponse, error) {
+	var lastErr error
+	broadcast := hasBroadcast(reqs)
+	for _, node := range p.ranked() {
+		start := time.Now()
+		resps, err := sendBatch(ctx, node.transport, reqs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			p.recordFailure(node, err.Error())
+			if broadcast && !notDelivered(err) {
+				return nil, errors.Wrapf(err, "node %s", node.url)
+			}
+			lastErr = errors.Wrapf(err, "node %s", node.url)
+			continue
+		}
+		p.recordSuccess(node, time.Since(start))
+		return resps, nil
+	}
+	return nil, errors.Wrap(lastErr, "all nodes failed")
+}
+
+// CheckHeads asks every node for its dynamic global properties concurrently
+// and updates each node's head block and lag behind the most advanced node.
+// Nodes that cannot answer count a failure.
+func (p *Pool) CheckHeads(ctx context.Context) error {
+	heads := make([]uint32, len(p.nodes))
+	var wg sync.WaitGroup
+	for i, node := range p.nodes {
+		wg.Add(1)
+		go func(i int, node *poolNode) {
+			defer wg.Done()
+			start := time.Now()
+			resp, err := node.transport.Send(ctx, &RPCRequest{
+				JsonRpc: "2.0",
+				ID:      1,
+				Method:  "condenser_api.get_dynamic_global_properties",
+				Params:  []interface{}{},
+			})
+			if err == nil && resp.HasError() {
+				err = errors.Errorf("RPC error: %s", resp.Error)
+			}
+			var dgp struct {
+				HeadBlockNumber uint32 `json:"head_block_number"`
+			}
+			if err == nil {
+				err = json.Unmarshal(resp.Result, &dgp)
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					p.recordFailure(node, err.Error())
+				}
+				return
+			}
+			p.recordSuccess(node, time.Since(start))
+			heads[i] = dgp.HeadBlockNumber
+		}(i, node)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var best uint32
+	for _, h := range heads {
+		if h > best {
+			best = h
+		}
+	}
+	if best == 0 {
+		return errors.New("no node reported a head block")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, node := range p.nodes {
+		if heads[i] == 0 {
+			continue // keep the previous reading for nodes that did not answer
+		}
+		node.headBlock = heads[i]
+		node.lag = best - heads[i]
+	}
+	return nil
+}
+
+// Monitor runs CheckHeads every interval until ctx is done. Run it in its own
+// goroutine to keep lag readings fresh.
+func (p *Pool) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.CheckHeads(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// State returns a snapshot of every node in configuration order.
+func (p *Pool) State() []NodeState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	states := make([]NodeState, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		states = append(states, NodeState{
+			URL:                 n.url,
+			Healthy:             p.healthyLocked(n, now),
+			Latency:             n.latency,
+			Requests:            n.requests,
+			Errors:              n.errors,
+			ConsecutiveFailures: n.consecutiveFailures,
+			LastError:           n.lastError,
+			HeadBlock:           n.headBlock,
+			Lag:                 n.lag,
+		})
+	}
+	return states
+}
+
+// Close closes every node transport that holds resources.
+func (p *Pool) Close() error {
+	var firstErr error
+	for _, n := range p.nodes {
+		if closer, ok := n.transport.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// ranked returns the nodes healthiest first: healthy before demoted, then
+// fewer consecutive failures, then lower latency.
+func (p *Pool) ranked() []*poolNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	nodes := make([]*poolNode, len(p.nodes))
+	copy(nodes, p.nodes)
+	healthy := make(map[*poolNode]bool, len(nodes))
+	for _, n := range nodes {
+		healthy[n] = p.healthyLocked(n, now)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if healthy[a] != healthy[b] {
+			return healthy[a]
+		}
+		if a.consecutiveFailures != b.consecutiveFailures {
+			return a.consecutiveFailures < b.consecutiveFailures
+		}
+		return a.latency < b.latency
+	})
+	return nodes
+}
+
+func (p *Pool) healthyLocked(n *poolNode, now time.Time) bool {
+	if now.Before(n.demotedUntil) {
+		return false
+	}
+	return n.lag <= p.lagThreshold
+}
+
+func (p *Pool) recordSuccess(n *poolNode, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.requests++
+	n.consecutiveFailures = 0
+	if n.latency == 0 {
+		n.latency = latency
+	} else {
+		// EWMA with alpha 0.3: recent samples dominate, one outlier does not.
+		n.latency = (n.latency*7 + latency*3) / 10
+	}
+}
+
+func (p *Pool) recordFailure(n *poolNode, reason string) {
+	p.mu.Lock()
+	n.requests++
+	n.errors++
+	n.consecutiveFailures++
+	n.lastError = reason
+	failures := n.consecutiveFailures
+	demoted := p.maxFailures > 0 && failures >= p.maxFailures
+	if demoted {
+		n.demotedUntil = time.Now().Add(p.cooldown)
+	}
+	p.mu.Unlock()
+
+	ctx := context.Background()
+	p.logger.Log(ctx, LevelWarn, "node failed", "url", n.url, "error", reason, "consecutive_failures", failures)
+	if demoted {
+		p.logger.Log(ctx, LevelWarn, "node demoted", "url", n.url, "cooldown", p.cooldown)
+	}
+}
+
+// rpcMethodNotFound is the JSON-RPC error code for an unknown method; steemd
+// answers with it when the API plugin serving the method is not enabled.
+const rpcMethodNotFound = -32601
+
+// isNodeTroubleRPCError reports whether a JSON-RPC error object describes a
+// problem with the node itself (overloaded, missing plugin, internal failure)
+// rather than with the request, so another node may well succeed.
+func isNodeTroubleRPCError(raw json.RawMessage) bool {
+	var rpcErr struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &rpcErr); err != nil {
+		return false
+	}
+	switch rpcErr.Code {
+	case -32603: // JSON-RPC internal error
+		return true
+	case rpcMethodNotFound: // the API plugin is not enabled on this node
+		return true
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	for _, hint := range []string{"unable to acquire database lock", "timeout", "too many requests", "service unavailable"} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
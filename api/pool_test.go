@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// fakeNodes maps a node URL to a funcTransport and counts calls per node.
+type fakeNodes struct {
+	mu       sync.Mutex
+	handlers map[string]funcTransport
+	calls    map[string]int
+}
+
+func newFakeNodes(handlers map[string]funcTransport) *fakeNodes {
+	return &fakeNodes{handlers: handlers, calls: map[string]int{}}
+}
+
+func (f *fakeNodes) transport(url string) Transport {
+	return funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		f.mu.Lock()
+		f.calls[url]++
+		f.mu.Unlock()
+		return f.handlers[url](ctx, req)
+	})
+}
+
+func (f *fakeNodes) count(url string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[url]
+}
+
+func okNode(result string) funcTransport {
+	return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(result)}, nil
+	}
+}
+
+func errNode(err error) funcTransport {
+	return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return nil, err
+	}
+}
+
+func rpcErrNode(rpcErr string) funcTransport {
+	return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{ID: req.ID, Error: json.RawMessage(rpcErr)}, nil
+	}
+}
+
+func headNode(head int) funcTransport {
+	return okNode(fmt.Sprintf(`{"head_block_number":%d}`, head))
+}
+
+func newTestPool(t *testing.T, nodes *fakeNodes, urls []string, opts ...PoolOption) *Pool {
+	t.Helper()
+	opts = append([]PoolOption{WithPoolNodeTransport(nodes.transport)}, opts...)
+	pool, err := NewPool(urls, opts...)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	return pool
+}
+
+func TestNewPool_NoURLs(t *testing.T) {
+	if _, err := NewPool(nil); err == nil {
+		t.Error("expected error for empty pool")
+	}
+}
+
+func TestPool_FailoverOnTransportError(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": errNode(errors.New("connection refused")),
+		"http://b": okNode(`["alice"]`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+	api := NewAPI("http://a", WithTransport(pool))
+
+	names, err := api.LookupAccounts("a", 1)
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if len(names) != 1 || names[0] != "alice" {
+		t.Errorf("unexpected result: %v", names)
+	}
+
+	state := pool.State()
+	if state[0].Errors != 1 || state[0].ConsecutiveFailures != 1 || state[0].LastError == "" {
+		t.Errorf("expected node a to record the failure, got %+v", state[0])
+	}
+	if state[1].Requests != 1 || state[1].Errors != 0 {
+		t.Errorf("expected node b to record a success, got %+v", state[1])
+	}
+}
+
+func TestPool_FailoverOnNodeTroubleRPCError(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": rpcErrNode(`{"code":-32603,"message":"Internal Error"}`),
+		"http://b": okNode(`["bob"]`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+	api := NewAPI("http://a", WithTransport(pool))
+
+	names, err := api.LookupAccounts("b", 1)
+	if err != nil || len(names) != 1 || names[0] != "bob" {
+		t.Fatalf("expected failover to node b, got %v, %v", names, err)
+	}
+}
+
+// TestPool_MethodNotFound checks a node without the API plugin is skipped,
+// while an API over a pool where no node serves the method gives up at once.
+func TestPool_MethodNotFound(t *testing.T) {
+	const notFound = `{"code":-32601,"message":"Could not find method lookup_accounts"}`
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": rpcErrNode(notFound),
+		"http://b": okNode(`["bob"]`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+	api := NewAPI("http://a", WithTransport(pool), fastRetry(3))
+
+	names, err := api.LookupAccounts("b", 1)
+	if err != nil || len(names) != 1 || names[0] != "bob" {
+		t.Fatalf("expected failover to node b, got %v, %v", names, err)
+	}
+
+	nodes = newFakeNodes(map[string]funcTransport{"http://a": rpcErrNode(notFound)})
+	pool = newTestPool(t, nodes, []string{"http://a"})
+	api = NewAPI("http://a", WithTransport(pool), fastRetry(3))
+	if _, err := api.LookupAccounts("b", 1); err == nil {
+		t.Fatal("expected method-not-found error")
+	}
+	if got := nodes.count("http://a"); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
+
+// TestPool_DeterministicRPCErrorNotRetried checks a chain assertion is
+// returned straight away: every node would reject the request the same way.
+func TestPool_DeterministicRPCErrorNotRetried(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": rpcErrNode(`{"code":-32000,"message":"Assert Exception:false: missing required posting authority"}`),
+		"http://b": okNode(`{}`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+	api := NewAPI("http://a", WithTransport(pool))
+
+	_, err := api.Call("condenser_api", "broadcast_transaction", []interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "missing required posting authority") {
+		t.Fatalf("expected assertion error, got %v", err)
+	}
+	if nodes.count("http://b") != 0 {
+		t.Error("deterministic RPC error must not fail over")
+	}
+	if state := pool.State(); state[0].Errors != 0 {
+		t.Errorf("deterministic RPC error must not count against the node, got %+v", state[0])
+	}
+}
+
+// TestPool_BroadcastNoFailoverAfterAmbiguousError checks a broadcast that may
+// have reached node a is not sent to node b as well.
+func TestPool_BroadcastNoFailoverAfterAmbiguousError(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": errNode(&HTTPStatusError{StatusCode: http.StatusGatewayTimeout}),
+		"http://b": okNode(`{}`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+
+	_, err := pool.Send(context.Background(), &RPCRequest{Method: "condenser_api.broadcast_transaction"})
+	if err == nil {
+		t.Fatal("expected the node a error")
+	}
+	if nodes.count("http://b") != 0 {
+		t.Error("an ambiguous broadcast failure must not fail over")
+	}
+	if state := pool.State(); state[0].Errors != 1 {
+		t.Errorf("expected node a to record the failure, got %+v", state[0])
+	}
+}
+
+// TestPool_BroadcastNoFailoverAfterNodeTroubleRPCError checks a broadcast that
+// timed out on node a, and may have been accepted there, is not sent to node b.
+func TestPool_BroadcastNoFailoverAfterNodeTroubleRPCError(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": rpcErrNode(`{"code":-32000,"message":"timeout waiting for the transaction"}`),
+		"http://b": okNode(`{}`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+
+	resp, err := pool.Send(context.Background(), &RPCRequest{Method: "condenser_api.broadcast_transaction_synchronous"})
+	if err != nil || resp == nil || !resp.HasError() {
+		t.Fatalf("expected the node a RPC error, got %v, %v", resp, err)
+	}
+	if nodes.count("http://b") != 0 {
+		t.Error("a broadcast answered with a node-trouble RPC error must not fail over")
+	}
+	if state := pool.State(); state[0].Errors != 1 {
+		t.Errorf("expected node a to record the failure, got %+v", state[0])
+	}
+}
+
+func TestPool_AllNodesFail(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": errNode(errors.New("boom a")),
+		"http://b": errNode(errors.New("boom b")),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"})
+
+	_, err := pool.Send(context.Background(), &RPCRequest{Method: "condenser_api.get_config"})
+	if err == nil || !strings.Contains(err.Error(), "all nodes failed") {
+		t.Errorf("expected all-nodes error, got %v", err)
+	}
+}
+
+func TestPool_DemotesAfterMaxFailures(t *testing.T) {
+	var aFails = true
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+			if aFails {
+				return nil, errors.New("timeout")
+			}
+			return &RPCResponse{ID: req.ID, Result: json.RawMessage(`[]`)}, nil
+		},
+		"http://b": okNode(`[]`),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b"}, WithPoolMaxFailures(1, time.Hour))
+
+	send := func() {
+		if _, err := pool.Send(context.Background(), &RPCRequest{Method: "condenser_api.lookup_accounts"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	send()
+	aFails = false
+	send()
+	send()
+
+	if got := nodes.count("http://a"); got != 1 {
+		t.Errorf("expected demoted node a to be skipped after its failure, got %d calls", got)
+	}
+	if state := pool.State(); state[0].Healthy {
+		t.Errorf("expected node a to be reported unhealthy, got %+v", state[0])
+	}
+}
+
+func TestPool_CheckHeadsDemotesLaggingNode(t *testing.T) {
+	nodes := newFakeNodes(map[string]funcTransport{
+		"http://a": headNode(50),
+		"http://b": headNode(100),
+		"http://c": headNode(98),
+	})
+	pool := newTestPool(t, nodes, []string{"http://a", "http://b", "http://c"}, WithPoolLagThreshold(5))
+
+	if err := pool.CheckHeads(context.Background()); err != nil {
+		t.Fatalf("CheckHeads failed: %v", err)
+	}
+
+	state := pool.State()
+	if state[0].HeadBlock != 50 || state[0].Lag != 50 || state[0].Healthy {
+		t.Errorf("expected node a to lag by 50 and be unhealthy, got %+v", state[0])
+	}
+	if state[1].Lag != 0 || !state[1].Healthy {
+		t.Errorf("expected node b to be the head, got %+v", state[1])
+	}
+	if state[2].Lag != 2 || !state[2].Healthy {
+		t.Errorf("expected node c within threshold, got %+v", state[2])
+	}
+	if ranked := pool.ranked(); ranked[len(ranked)-1].url != "http://a" {
+		t.Errorf("expected lagging node a to be ranked last, got %s", ranked[len(ranked)-1].url)
+	}
+}
+
+func TestPool_SignedCallValidation(t *testing.T) {
+	pool, _ := NewPool([]string{"http://a", "https://b"})
+	if err := NewAPI("http://a", WithTransport(pool)).validateTransportForSignedCall(); err != nil {
+		t.Errorf("HTTP-only pool should allow signed calls, got %v", err)
+	}
+
+	pool, _ = NewPool([]string{"http://a", "wss://b"})
+	if err := NewAPI("http://a", WithTransport(pool)).validateTransportForSignedCall(); err == nil {
+		t.Error("pool containing a WebSocket node must reject signed calls")
+	}
+}
+
+func TestNewPoolAPI(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddAccount("carol", nil)
+	api, pool, err := NewPoolAPI([]string{"http://127.0.0.1:1", node.URL}, nil)
+	if err != nil {
+		t.Fatalf("NewPoolAPI failed: %v", err)
+	}
+	names, err := api.LookupAccounts("c", 1)
+	if err != nil || len(names) != 1 || names[0] != "carol" {
+		t.Fatalf("expected failover to the live node, got %v, %v", names, err)
+	}
+	if len(pool.State()) != 2 {
+		t.Errorf("expected 2 nodes in state, got %d", len(pool.State()))
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the call latency
+// histogram of PrometheusMetrics.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics is a Metrics implementation that keeps its counters in
+// memory and serves them in the Prometheus text exposition format. It has no
+// dependencies beyond the standard library:
+//
+//	m := api.NewPrometheusMetrics()
+//	a := api.NewAPI(url, api.WithMetrics(m))
+//	http.Handle("/metrics", m)
+//
+// It exports:
+//
+//	steem_rpc_calls_total{method}                  calls; a retried call counts once
+//	steem_rpc_errors_total{method,class}           failed calls by ErrorClass
+//	steem_rpc_call_duration_seconds{method}        call latency histogram
+//	steem_rpc_retries_total{method}                retries
+//	steem_broadcasts_total{mode,result}            broadcasts: "success" or the ErrorClass
+type PrometheusMetrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	calls      map[string]*latencyHistogram // by method
+	errors     map[[2]string]uint64
+	retries    map[string]uint64
+	broadcasts map[[2]string]uint64
+}
+
+type latencyHistogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusMetrics creates a PrometheusMetrics whose latency histogram
+// uses buckets, upper bounds in seconds (DefaultLatencyBuckets if none).
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &PrometheusMetrics{
+		buckets:    buckets,
+		calls:      make(map[string]*latencyHistogram),
+		errors:     make(map[[2]string]uint64),
+		retries:    make(map[string]uint64),
+		broadcasts: make(map[[2]string]uint64),
+	}
+}
+
+func (m *PrometheusMetrics) ObserveCall(method string, d time.Duration, class string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.calls[method]
+	if !ok {
+		l = &latencyHistogram{counts: make([]uint64, len(m.buckets))}
+		m.calls[method] = l
+	}
+	seconds := d.Seconds()
+	if i := sort.SearchFloat64s(m.buckets, seconds); i < len(m.buckets) {
+		l.counts[i]++
+	}
+	l.count++
+	l.sum += seconds
+	if class != "" {
+		m.errors[[2]string{method, class}]++
+	}
+}
+
+func (m *PrometheusMetrics) ObserveRetry(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[method]++
+}
+
+func (m *PrometheusMetrics) ObserveBroadcast(mode string, class string) {
+	if class == "" {
+		class = "success"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcasts[[2]string{mode, class}]++
+}
+
+// ServeHTTP serves the metrics in the text exposition format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the text exposition format, series
+// sorted by label values.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	methods := make([]string, 0, len(m.calls))
+	for method := range m.calls {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	writeMetricHeader(cw, "steem_rpc_calls_total", "counter", "RPC calls by method.")
+	for _, method := range methods {
+		fmt.Fprintf(cw, "steem_rpc_calls_total{method=%s} %d\n", labelValue(method), m.calls[method].count)
+	}
+
+	writeMetricHeader(cw, "steem_rpc_errors_total", "counter", "Failed RPC calls by method and error class.")
+	for _, key := range sortedPairs(m.errors) {
+		fmt.Fprintf(cw, "steem_rpc_errors_total{method=%s,class=%s} %d\n", labelValue(key[0]), labelValue(key[1]), m.errors[key])
+	}
+
+	writeMetricHeader(cw, "steem_rpc_call_duration_seconds", "histogram", "RPC call latency by method, retries included.")
+	for _, method := range methods {
+		l := m.calls[method]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += l.counts[i]
+			fmt.Fprintf(cw, "steem_rpc_call_duration_seconds_bucket{method=%s,le=\"%s\"} %d\n",
+				labelValue(method), strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(cw, "steem_rpc_call_duration_seconds_bucket{method=%s,le=\"+Inf\"} %d\n", labelValue(method), l.count)
+		fmt.Fprintf(cw, "steem_rpc_call_duration_seconds_sum{method=%s} %s\n", labelValue(method), strconv.FormatFloat(l.sum, 'g', -1, 64))
+		fmt.Fprintf(cw, "steem_rpc_call_duration_seconds_count{method=%s} %d\n", labelValue(method), l.count)
+	}
+
+	writeMetricHeader(cw, "steem_rpc_retries_total", "counter", "RPC retries by method.")
+	retried := make([]string, 0, len(m.retries))
+	for method := range m.retries {
+		retried = append(retried, method)
+	}
+	sort.Strings(retried)
+	for _, method := range retried {
+		fmt.Fprintf(cw, "steem_rpc_retries_total{method=%s} %d\n", labelValue(method), m.retries[method])
+	}
+
+	writeMetricHeader(cw, "steem_broadcasts_total", "counter", "Transaction broadcasts by mode and result.")
+	for _, key := range sortedPairs(m.broadcasts) {
+		fmt.Fprintf(cw, "steem_broadcasts_total{mode=%s,result=%s} %d\n", labelValue(key[0]), labelValue(key[1]), m.broadcasts[key])
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labelValue renders a label value, escaping backslashes, quotes and newlines.
+func labelValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// countingWriter counts the bytes written and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
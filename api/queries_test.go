@@ -2,99 +2,11 @@ package api
 
 import (
 	"encoding/json"
-	"io"
-	"net/http"
-	"net/http/httptest"
 	"testing"
-)
+	"time"
 
-// mockRPCServer returns a test server that routes JSON-RPC requests by the
-// "method" field in the request body, returning the corresponding canned
-// response. This makes G2/G1 tests deterministic and offline (no dependency on
-// api.steemit.com). The returned server is closed automatically via t.Cleanup.
-//
-// Each entry in responses maps an "<apiName>.<method>" string to a JSON-RPC
-// response object (will be marshalled as the full {jsonrpc,result} envelope,
-// with the result field taken verbatim from the provided value).
-func mockRPCServer(t *testing.T, responses map[string]interface{}) *httptest.Server {
-	t.Helper()
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		var req struct {
-			Method string `json:"method"`
-		}
-		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		result, ok := responses[req.Method]
-		if !ok {
-			http.Error(w, "no mock for method: "+req.Method, http.StatusBadRequest)
-			return
-		}
-		// Emit a minimal JSON-RPC 2.0 success envelope with the canned result.
-		envelope := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"id":      1,
-			"result":  result,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(envelope)
-	}))
-	t.Cleanup(server.Close)
-	return server
-}
-
-// capturedRequest records one inbound JSON-RPC request's method + raw params,
-// so tests can assert the EXACT wire shape (positional array vs named object,
-// element order, types) — not just the response handling. This closes the gap
-// where an overly-permissive mock would pass even if the SDK sent the wrong
-// param shape (e.g. the database_api named-object bug this mock would have
-// caught if it had checked params).
-type capturedRequest struct {
-	Method string
-	Params json.RawMessage
-}
-
-// mockRPCServerCapture is like mockRPCServer but also records each request's
-// method and raw params into *captured for the test to inspect.
-func mockRPCServerCapture(t *testing.T, responses map[string]interface{}, captured *[]capturedRequest) *httptest.Server {
-	t.Helper()
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		var req struct {
-			Method string          `json:"method"`
-			Params json.RawMessage `json:"params"`
-		}
-		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		*captured = append(*captured, capturedRequest{Method: req.Method, Params: req.Params})
-		result, ok := responses[req.Method]
-		if !ok {
-			http.Error(w, "no mock for method: "+req.Method, http.StatusBadRequest)
-			return
-		}
-		envelope := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"id":      1,
-			"result":  result,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(envelope)
-	}))
-	t.Cleanup(server.Close)
-	return server
-}
+	"github.com/steemit/steemgosdk/steemtest"
+)
 
 // assertParamsArray asserts that raw is a JSON positional array and returns
 // its decoded elements. Fails the test if raw is not an array.
@@ -108,32 +20,19 @@ func assertParamsArray(t *testing.T, raw json.RawMessage) []interface{} {
 }
 
 func TestGetAccounts(t *testing.T) {
-	// Real-shaped condenser_api.get_accounts response: an array with one
-	// ExtendedAccount whose posting authority has a single key_auth pair
-	// ["STM...", 1]. This also exercises steemutil's KeyAuth.UnmarshalJSON
-	// (nested array-of-pairs flattening).
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": []map[string]interface{}{
-			{
-				"name":    "testaccount",
-				"created": "2016-01-01T00:00:00Z",
-				"posting": map[string]interface{}{
-					"weight_threshold": 1,
-					"account_auths":    []interface{}{},
-					"key_auths":        []interface{}{[]interface{}{"STM7jNh5ejQoqHqWcGWFJ1v4F5CzsG3EiBuz1VooCng1cH5QpJD27", 1}},
-				},
-				"active": map[string]interface{}{
-					"weight_threshold": 1,
-					"account_auths":    []interface{}{},
-					"key_auths":        []interface{}{[]interface{}{"STM7W7ACQDZJZ6rZGKeT9auipnSiSxFxJ4k71QXmrhY9HbvYsNnQ2", 1}},
-				},
-				"balance":       "1000.000 STEEM",
-				"voting_power":  9000,
-				"reputation":    "1234567890",
-			},
-		},
+	// The node answers with a real-shaped ExtendedAccount whose posting
+	// authority has a single key_auth pair ["STM...", 1]. This also exercises
+	// steemutil's KeyAuth.UnmarshalJSON (nested array-of-pairs flattening).
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddAccount("testaccount", map[string]interface{}{
+		"posting":      steemtest.KeyAuthority("STM7jNh5ejQoqHqWcGWFJ1v4F5CzsG3EiBuz1VooCng1cH5QpJD27"),
+		"active":       steemtest.KeyAuthority("STM7W7ACQDZJZ6rZGKeT9auipnSiSxFxJ4k71QXmrhY9HbvYsNnQ2"),
+		"balance":      "1000.000 STEEM",
+		"voting_power": 9000,
+		"reputation":   "1234567890",
 	})
-	api := NewAPI(server.URL)
+	api := NewAPI(node.URL)
 
 	accts, err := api.GetAccounts([]string{"testaccount"})
 	if err != nil {
@@ -158,17 +57,19 @@ func TestGetAccounts(t *testing.T) {
 	if accts[0].Posting.WeightThreshold != 1 {
 		t.Errorf("expected weight_threshold 1, got %d", accts[0].Posting.WeightThreshold)
 	}
+	if accts[0].Balance != "1000.000 STEEM" {
+		t.Errorf("expected balance 1000.000 STEEM, got %s", accts[0].Balance)
+	}
 }
 
 func TestGetFollowCount(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_follow_count": map[string]interface{}{
-			"account":         "testaccount",
-			"follower_count":  42,
-			"following_count": 7,
-		},
-	})
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.Follow("alice", "testaccount")
+	node.Follow("bob", "testaccount")
+	node.Follow("carol", "testaccount", "ignore")
+	node.Follow("testaccount", "dave")
+	api := NewAPI(node.URL)
 
 	fc, err := api.GetFollowCount("testaccount")
 	if err != nil {
@@ -177,22 +78,20 @@ func TestGetFollowCount(t *testing.T) {
 	if fc.Account != "testaccount" {
 		t.Errorf("expected account testaccount, got %s", fc.Account)
 	}
-	if fc.FollowerCount != 42 {
-		t.Errorf("expected 42 followers, got %d", fc.FollowerCount)
+	if fc.FollowerCount != 2 {
+		t.Errorf("expected 2 followers, got %d", fc.FollowerCount)
 	}
-	if fc.FollowingCount != 7 {
-		t.Errorf("expected 7 following, got %d", fc.FollowingCount)
+	if fc.FollowingCount != 1 {
+		t.Errorf("expected 1 following, got %d", fc.FollowingCount)
 	}
 }
 
 func TestGetFollowers(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_followers": []map[string]interface{}{
-			{"follower": "alice", "following": "testaccount", "what": []string{"blog"}},
-			{"follower": "bob", "following": "testaccount", "what": []string{"blog"}},
-		},
-	})
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.Follow("bob", "testaccount")
+	node.Follow("alice", "testaccount")
+	api := NewAPI(node.URL)
 
 	followers, err := api.GetFollowers("testaccount", "", "blog", 100)
 	if err != nil {
@@ -210,12 +109,11 @@ func TestGetFollowers(t *testing.T) {
 }
 
 func TestGetFollowing(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_following": []map[string]interface{}{
-			{"follower": "testaccount", "following": "carol", "what": []string{"blog"}},
-		},
-	})
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.Follow("testaccount", "carol")
+	node.Follow("alice", "testaccount")
+	api := NewAPI(node.URL)
 
 	following, err := api.GetFollowing("testaccount", "", "blog", 100)
 	if err != nil {
@@ -230,10 +128,12 @@ func TestGetFollowing(t *testing.T) {
 }
 
 func TestLookupAccounts(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.lookup_accounts": []string{"alice", "alice2", "alicetest"},
-	})
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	for _, name := range []string{"alicetest", "alice", "alex", "alice2"} {
+		node.AddAccount(name, nil)
+	}
+	api := NewAPI(node.URL)
 
 	names, err := api.LookupAccounts("alic", 10)
 	if err != nil {
@@ -248,17 +148,11 @@ func TestLookupAccounts(t *testing.T) {
 }
 
 func TestGetOrderBook(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_order_book": map[string]interface{}{
-			"asks": []map[string]interface{}{
-				{"order_price": map[string]interface{}{"base": "1.000 SBD", "quote": "2.000 STEEM"}},
-			},
-			"bids": []map[string]interface{}{
-				{"order_price": map[string]interface{}{"base": "0.400 SBD", "quote": "1.000 STEEM"}},
-			},
-		},
-	})
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddOrder("alice", "2.000 STEEM", "1.000 SBD")
+	node.AddOrder("bob", "0.400 SBD", "1.000 STEEM")
+	api := NewAPI(node.URL)
 
 	ob, err := api.GetOrderBook(1)
 	if err != nil {
@@ -267,8 +161,8 @@ func TestGetOrderBook(t *testing.T) {
 	if len(ob.Asks) != 1 || len(ob.Bids) != 1 {
 		t.Fatalf("expected 1 ask + 1 bid, got %d asks, %d bids", len(ob.Asks), len(ob.Bids))
 	}
-	if ob.Asks[0].OrderPrice.Base != "1.000 SBD" {
-		t.Errorf("expected ask base 1.000 SBD, got %s", ob.Asks[0].OrderPrice.Base)
+	if ob.Asks[0].OrderPrice.Base != "2.000 STEEM" {
+		t.Errorf("expected ask base 2.000 STEEM, got %s", ob.Asks[0].OrderPrice.Base)
 	}
 	if ob.Bids[0].OrderPrice.Quote != "1.000 STEEM" {
 		t.Errorf("expected bid quote 1.000 STEEM, got %s", ob.Bids[0].OrderPrice.Quote)
@@ -276,15 +170,11 @@ func TestGetOrderBook(t *testing.T) {
 }
 
 func TestGetFeedHistory(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_feed_history": map[string]interface{}{
-			"price_history": []map[string]interface{}{
-				{"base": "0.500 SBD", "quote": "1.000 STEEM"},
-				{"base": "0.510 SBD", "quote": "1.000 STEEM"},
-			},
-		},
-	})
-	api := NewAPI(server.URL)
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddFeedPrice("0.500 SBD", "1.000 STEEM")
+	node.AddFeedPrice("0.510 SBD", "1.000 STEEM")
+	api := NewAPI(node.URL)
 
 	fh, err := api.GetFeedHistory()
 	if err != nil {
@@ -301,37 +191,30 @@ func TestGetFeedHistory(t *testing.T) {
 
 func TestGetAccountHistory(t *testing.T) {
 	// Real condenser_api.get_account_history wire shape: array of
-	// [index, {"op":[type, payload], "timestamp": ts}] tuples. This exercises
-	// AccountHistoryEntry.UnmarshalJSON (the local custom unmarshaler).
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_account_history": []interface{}{
-			[]interface{}{float64(10), map[string]interface{}{
-				"op": []interface{}{
-					"transfer",
-					map[string]interface{}{
-						"from":   "alice",
-						"to":     "bob",
-						"amount": "1.000 STEEM",
-						"memo":   "hello",
-					},
-				},
-				"timestamp": "2026-01-01T00:00:00Z",
-			}},
-			[]interface{}{float64(9), map[string]interface{}{
-				"op": []interface{}{
-					"vote",
-					map[string]interface{}{
-						"voter":    "alice",
-						"author":   "carol",
-						"permlink": "post1",
-						"weight":   float64(10000),
-					},
-				},
-				"timestamp": "2026-01-02T00:00:00Z",
-			}},
-		},
+	// [index, {"op":[type, payload], "timestamp": ts}] tuples, oldest first.
+	// This exercises AccountHistoryEntry.UnmarshalJSON (the local custom
+	// unmarshaler).
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddHistoryEntry("bob", steemtest.HistoryEntry{
+		Op: steemtest.Op{Type: "transfer", Value: map[string]interface{}{
+			"from":   "alice",
+			"to":     "bob",
+			"amount": "1.000 STEEM",
+			"memo":   "hello",
+		}},
+		Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	node.AddHistoryEntry("bob", steemtest.HistoryEntry{
+		Op: steemtest.Op{Type: "vote", Value: map[string]interface{}{
+			"voter":    "bob",
+			"author":   "carol",
+			"permlink": "post1",
+			"weight":   10000,
+		}},
+		Timestamp: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
 	})
-	api := NewAPI(server.URL)
+	api := NewAPI(node.URL)
 
 	entries, err := api.GetAccountHistory("bob", -1, 1000)
 	if err != nil {
@@ -341,9 +224,9 @@ func TestGetAccountHistory(t *testing.T) {
 		t.Fatalf("expected 2 entries, got %d", len(entries))
 	}
 
-	// First entry: transfer op at index 10.
-	if entries[0].Index != 10 {
-		t.Errorf("expected index 10, got %d", entries[0].Index)
+	// First entry: transfer op at index 0.
+	if entries[0].Index != 0 {
+		t.Errorf("expected index 0, got %d", entries[0].Index)
 	}
 	if entries[0].Op.Type != "transfer" {
 		t.Errorf("expected op type transfer, got %s", entries[0].Op.Type)
@@ -351,13 +234,13 @@ func TestGetAccountHistory(t *testing.T) {
 	if entries[0].Op.Payload["to"] != "bob" {
 		t.Errorf("expected transfer to bob, got %v", entries[0].Op.Payload["to"])
 	}
-	if entries[0].Timestamp != "2026-01-01T00:00:00Z" {
+	if entries[0].Timestamp != "2026-01-01T00:00:00" {
 		t.Errorf("unexpected timestamp: %s", entries[0].Timestamp)
 	}
 
-	// Second entry: vote op at index 9.
-	if entries[1].Index != 9 {
-		t.Errorf("expected index 9, got %d", entries[1].Index)
+	// Second entry: vote op at index 1.
+	if entries[1].Index != 1 {
+		t.Errorf("expected index 1, got %d", entries[1].Index)
 	}
 	if entries[1].Op.Type != "vote" {
 		t.Errorf("expected op type vote, got %s", entries[1].Op.Type)
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy controls how API calls that fail for transient reasons are
+// retried. It applies uniformly to every request an API sends, including
+// signed calls and the broadcasts made through broadcast.Broadcast.
+//
+// Only failures that another attempt could fix are retried: transport errors
+// (connection failures, 5xx/429 responses, dropped WebSocket connections) and
+// RPC errors that point at a passing problem of the node (see
+// isTransientRPCError). Deterministic RPC errors, such as a failed chain
+// assertion or a method the node does not serve, are returned immediately.
+//
+// Broadcasts are the exception: a timeout, a 5xx response or a dropped
+// connection may come after the node accepted the transaction, and resending
+// it would then fail as a duplicate. Broadcast methods are therefore only
+// retried when the request provably never reached the node (see notDelivered).
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt; 0
+	// disables retrying.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry. Each following
+	// delay is multiplied by Multiplier, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each delay by up to this fraction (0.2 = ±20%), so
+	// clients that failed together do not retry in lockstep.
+	Jitter float64
+	// Retryable classifies transport errors. Nil means IsRetryable.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy returns the policy NewAPI starts with: 5 retries with
+// exponential backoff from 100ms, capped at 2s, with 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Backoff returns the delay before retry number retry (starting at 1).
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		d *= p.Multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// retryable reports whether the outcome of a request is worth another
+// attempt; broadcast marks requests that push a transaction to the chain.
+func (p RetryPolicy) retryable(broadcast bool, resp *RPCResponse, err error) bool {
+	if broadcast {
+		return err != nil && notDelivered(err) && p.retryableError(err)
+	}
+	if err != nil {
+		return p.retryableError(err)
+	}
+	return resp != nil && resp.HasError() && isTransientRPCError(resp.Error)
+}
+
+func (p RetryPolicy) retryableError(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// isBroadcastMethod reports whether method pushes a transaction or block to
+// the chain, e.g. condenser_api.broadcast_transaction_synchronous or
+// network_broadcast_api.broadcast_transaction.
+func isBroadcastMethod(method string) bool {
+	return strings.HasPrefix(method[strings.LastIndex(method, ".")+1:], "broadcast_")
+}
+
+// hasBroadcast reports whether any of reqs is for a broadcast method.
+func hasBroadcast(reqs []*RPCRequest) bool {
+	for _, req := range reqs {
+		if isBroadcastMethod(callName(req.Method, req.Params)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransientRPCError reports whether asking the same node again may help.
+// A missing method is node trouble a Pool fails over for, but the node keeps
+// reporting it however often it is asked.
+func isTransientRPCError(raw json.RawMessage) bool {
+	var rpcErr struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(raw, &rpcErr) == nil && rpcErr.Code == rpcMethodNotFound {
+		return false
+	}
+	return isNodeTroubleRPCError(raw)
+}
+
+// notDelivered reports whether err proves the node never processed the
+// request: the connection could not be established, or the node turned the
+// request away unread with 408 or 429.
+func notDelivered(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// IsRetryable reports whether an error is transient. Cancellation, a closed
+// transport, unresolvable host names, 4xx statuses (other than 408 and 429)
+// and RPC errors about the request itself are permanent; other network,
+// status and decoding failures, and RPC errors about the node, are worth
+// another attempt.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrTransportClosed) {
+		return false
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return isTransientRPCError(rpcErr.Raw)
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusRequestTimeout,
+			statusErr.StatusCode == http.StatusTooManyRequests,
+			statusErr.StatusCode >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+func fastRetry(n int) Option {
+	return WithRetryPolicy(RetryPolicy{
+		MaxRetries:     n,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		Multiplier:     2,
+	})
+}
+
+// statusNode answers the first failures requests with status, as a proxy in
+// front of a troubled node does, and serves account alice after that.
+func statusNode(t *testing.T, status, failures int) *steemtest.Node {
+	t.Helper()
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	node.AddAccount("alice", nil)
+	node.FailHTTP(status, failures)
+	return node
+}
+
+func TestRetry_TransientHTTPStatus(t *testing.T) {
+	node := statusNode(t, http.StatusBadGateway, 2)
+	api := NewAPI(node.URL, fastRetry(3))
+
+	names, err := api.LookupAccounts("a", 1)
+	if err != nil || len(names) != 1 {
+		t.Fatalf("expected success after retries, got %v, %v", names, err)
+	}
+	if got := node.Posts(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetry(t *testing.T) {
+	node := statusNode(t, http.StatusServiceUnavailable, 100)
+	api := NewAPI(node.URL, fastRetry(5))
+	api.SetMaxRetry(2)
+
+	_, err := api.LookupAccounts("a", 1)
+	if err == nil || !strings.Contains(err.Error(), "gave up after 3 attempt(s)") {
+		t.Fatalf("expected bounded retries, got %v", err)
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected HTTPStatusError in chain, got %v", err)
+	}
+	if got := node.Posts(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetry_ClientErrorNotRetried(t *testing.T) {
+	node := statusNode(t, http.StatusBadRequest, 100)
+	api := NewAPI(node.URL, fastRetry(5))
+
+	if _, err := api.LookupAccounts("a", 1); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := node.Posts(); got != 1 {
+		t.Errorf("4xx must not be retried, got %d attempts", got)
+	}
+}
+
+func TestRetry_RPCErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		rpcErr   string
+		attempts int32
+	}{
+		{"assertion", `{"code":-32000,"message":"Assert Exception:false: missing required posting authority"}`, 1},
+		{"node trouble", `{"code":-32603,"message":"Internal Error"}`, 3},
+		{"method not found", `{"code":-32601,"message":"Could not find method get_accounts"}`, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+				atomic.AddInt32(&calls, 1)
+				return &RPCResponse{ID: req.ID, Error: json.RawMessage(tc.rpcErr)}, nil
+			})
+			api := NewAPI("http://node", WithTransport(transport), fastRetry(2))
+
+			_, err := api.Call("condenser_api", "get_accounts", []interface{}{})
+			if err == nil || !strings.Contains(err.Error(), "RPC error") {
+				t.Fatalf("expected RPC error, got %v", err)
+			}
+			if got := atomic.LoadInt32(&calls); got != tc.attempts {
+				t.Errorf("expected %d attempt(s), got %d", tc.attempts, got)
+			}
+		})
+	}
+}
+
+// TestRetry_UnknownMethodNotRetried checks the default policy gives up on a
+// method the node does not serve straight away instead of backing off.
+func TestRetry_UnknownMethodNotRetried(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	api := NewAPI(node.URL)
+
+	_, err := api.Call("condenser_api", "get_witness_schedule", []interface{}{})
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != rpcMethodNotFound {
+		t.Fatalf("expected method-not-found RPC error, got %v", err)
+	}
+	if got := len(node.Requests()); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
+
+// TestRetry_BroadcastNotResentAfterAmbiguousFailure has the node accept a
+// transaction and then drop the connection before answering. Resending would
+// fail as a duplicate, so the caller must get the transport error instead.
+func TestRetry_BroadcastNotResentAfterAmbiguousFailure(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.DropAnswers(1)
+
+	head, _ := node.Block(node.HeadBlockNum())
+	tx := map[string]interface{}{
+		"ref_block_num":    head.Num,
+		"ref_block_prefix": 0,
+		"expiration":       head.Timestamp.Add(time.Minute).Format("2006-01-02T15:04:05"),
+		"operations":       []interface{}{[]interface{}{"vote", map[string]interface{}{"voter": "alice", "author": "bob", "permlink": "p", "weight": 10000}}},
+		"extensions":       []interface{}{},
+		"signatures":       []interface{}{},
+	}
+	api := NewAPI(node.URL, fastRetry(3))
+
+	_, err := api.Call("condenser_api", "broadcast_transaction_synchronous", []interface{}{tx})
+	if err == nil {
+		t.Fatal("expected the dropped connection to be reported")
+	}
+	if errors.Is(err, ErrDuplicateTransaction) {
+		t.Errorf("the broadcast was resent: %v", err)
+	}
+	if got := node.Posts(); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+	if got := len(node.Broadcasts()); got != 1 {
+		t.Errorf("expected the node to hold the transaction once, got %d", got)
+	}
+}
+
+func TestRetry_BroadcastRetriedWhenNotDelivered(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		attempts int32
+	}{
+		{"dial", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, 2},
+		{"too many requests", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, 2},
+		{"bad gateway", &HTTPStatusError{StatusCode: http.StatusBadGateway}, 1},
+		{"read", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					return nil, tc.err
+				}
+				return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+			})
+			api := NewAPI("http://node", WithTransport(transport), fastRetry(3))
+
+			api.Call("condenser_api", "broadcast_transaction", []interface{}{})
+			if got := atomic.LoadInt32(&calls); got != tc.attempts {
+				t.Errorf("expected %d attempt(s), got %d", tc.attempts, got)
+			}
+		})
+	}
+}
+
+func TestRetry_StopsOnContextCancel(t *testing.T) {
+	var calls int32
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("connection reset")
+	})
+	api := NewAPI("http://node", WithTransport(transport), WithRetryPolicy(RetryPolicy{
+		MaxRetries:     10,
+		InitialBackoff: time.Hour,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := api.CallContext(ctx, "condenser_api", "get_config", []interface{}{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected the backoff to be interrupted after 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w*time.Millisecond {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+
+	p.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		if got := p.Backoff(1); got < 80*time.Millisecond || got > 120*time.Millisecond {
+			t.Fatalf("jittered backoff %v out of ±20%% bounds", got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection refused"), true},
+		{&HTTPStatusError{StatusCode: 502}, true},
+		{&HTTPStatusError{StatusCode: 429}, true},
+		{&HTTPStatusError{StatusCode: 404}, false},
+		{errors.Wrap(context.Canceled, "send"), false},
+		{ErrTransportClosed, false},
+	}
+	for _, tc := range cases {
+		if got := IsRetryable(tc.err); got != tc.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+// TestGetBlocks_BoundedRetry checks the block fan-out reports a failing block
+// instead of retrying it forever.
+func TestGetBlocks_BoundedRetry(t *testing.T) {
+	var calls int32
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		atomic.AddInt32(&calls, 1)
+		params := req.Params.([]interface{})
+		if params[0].(uint) == 11 {
+			return nil, errors.New("connection reset")
+		}
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{"previous":"00"}`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport), fastRetry(2))
+
+	_, err := api.GetBlocks(10, 13)
+	if err == nil || !strings.Contains(err.Error(), "get block {11} error") {
+		t.Fatalf("expected block 11 to fail, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 5 {
+		t.Errorf("expected 2 successful calls plus 3 attempts for block 11, got %d", got)
+	}
+}
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -123,20 +127,46 @@ func TestAPI_validateTransportForSignedCall(t *testing.T) {
 func TestAPI_SeqNoIncrement(t *testing.T) {
 	api := NewAPI(testURL)
 	
-	initialSeqNo := api.seqNo
+	initialSeqNo := atomic.LoadInt64(&api.seqNo)
 	
 	// Make a signed call (will fail at network level, but should increment seqNo)
 	api.SignedCall(testMethod, testParams, testAccount, testPrivateKey)
 	
-	if api.seqNo != initialSeqNo+1 {
-		t.Errorf("Expected seqNo to increment from %d to %d, got %d", initialSeqNo, initialSeqNo+1, api.seqNo)
+	if got := atomic.LoadInt64(&api.seqNo); got != initialSeqNo+1 {
+		t.Errorf("Expected seqNo to increment from %d to %d, got %d", initialSeqNo, initialSeqNo+1, got)
 	}
 	
 	// Make another call
 	api.SignedCall(testMethod, testParams, testAccount, testPrivateKey)
 	
-	if api.seqNo != initialSeqNo+2 {
-		t.Errorf("Expected seqNo to increment to %d, got %d", initialSeqNo+2, api.seqNo)
+	if got := atomic.LoadInt64(&api.seqNo); got != initialSeqNo+2 {
+		t.Errorf("Expected seqNo to increment to %d, got %d", initialSeqNo+2, got)
+	}
+}
+
+// TestAPI_SeqNoConcurrent checks concurrent signed calls each take their own
+// sequence number; run with -race to catch an unsynchronized increment.
+func TestAPI_SeqNoConcurrent(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`[]`)}, nil
+	})
+	api := NewAPI("http://node", WithTransport(transport))
+
+	const calls = 20
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.SignedCall(testMethod, testParams, testAccount, testPrivateKey); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt64(&api.seqNo); got != calls {
+		t.Errorf("Expected seqNo %d after %d concurrent calls, got %d", calls, calls, got)
 	}
 }
 
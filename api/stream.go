@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BlockInterval is the Steem block production interval.
+const BlockInterval = 3 * time.Second
+
+// StreamMode selects which end of the chain StreamBlocks follows.
+type StreamMode int
+
+const (
+	// StreamHead follows the head block. Blocks are delivered as soon as
+	// they are produced but may still be dropped by a fork.
+	StreamHead StreamMode = iota
+	// StreamIrreversible follows the last irreversible block. Blocks lag the
+	// head by up to a minute but will never be reverted.
+	StreamIrreversible
+)
+
+func (m StreamMode) String() string {
+	switch m {
+	case StreamHead:
+		return "head"
+	case StreamIrreversible:
+		return "irreversible"
+	}
+	return "unknown"
+}
+
+// StreamOption configures StreamBlocks.
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	mode      StreamMode
+	interval  time.Duration
+	catchUp   int
+	buffer    int
+	forkDepth int
+
+	checkpointer Checkpointer
+}
+
+func defaultStreamConfig() streamConfig {
+	return streamConfig{
+		interval:  BlockInterval,
+		catchUp:   50,
+		buffer:    16,
+		forkDepth: 64,
+	}
+}
+
+// WithStreamInterval sets how long the stream sleeps once it has caught up
+// before polling the node again. Defaults to BlockInterval.
+func WithStreamInterval(d time.Duration) StreamOption {
+	return func(c *streamConfig) {
+		c.interval = d
+	}
+}
+
+// WithStreamCatchUp caps how many blocks are fetched in parallel per round
+// while the stream is behind. Defaults to 50.
+func WithStreamCatchUp(n int) StreamOption {
+	return func(c *streamConfig) {
+		if n > 0 {
+			c.catchUp = n
+		}
+	}
+}
+
+// WithStreamBuffer sets the capacity of the stream channel. Defaults to 16.
+func WithStreamBuffer(n int) StreamOption {
+	return func(c *streamConfig) {
+		if n >= 0 {
+			c.buffer = n
+		}
+	}
+}
+
+// streamState records why a stream stopped.
+type streamState struct {
+	done chan struct{}
+	mu   sync.Mutex
+	err  error
+}
+
+func newStreamState() streamState {
+	return streamState{done: make(chan struct{})}
+}
+
+func (s *streamState) finish(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	close(s.done)
+}
+
+// Err returns the error that stopped the stream, or ctx.Err() if it was
+// cancelled. It blocks until the stream has stopped, so call it after C is
+// closed.
+func (s *streamState) Err() error {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// BlockStream is a running block stream started by StreamBlocks. Receive
+// blocks from C; once C is closed, Err reports why the stream stopped.
+type BlockStream struct {
+	// C delivers blocks in strictly increasing block number order, without
+	// gaps.
+	C <-chan *WrapBlock
+
+	streamState
+	acks *acker
+}
+
+// Ack acknowledges that b, and every block before it, has been processed.
+// With WithCheckpointer the block is saved as the resume point; otherwise
+// Ack does nothing.
+func (s *BlockStream) Ack(b *WrapBlock) error {
+	return s.acks.ack(b)
+}
+
+// StreamBlocks follows the chain from block from (0 = the current head or
+// last irreversible block, depending on mode) until ctx is done or a block
+// cannot be fetched after the retry policy is exhausted. With
+// WithCheckpointer it resumes after the last acknowledged block instead.
+//
+// While behind, the stream catches up in rounds of at most WithStreamCatchUp
+// blocks fetched in parallel (or in batches with WithBatchSize). Once caught
+// up it polls the node every WithStreamInterval. Blocks are always delivered
+// in order:
+//
+//	stream := a.StreamBlocks(ctx, 0, api.StreamIrreversible)
+//	for b := range stream.C {
+//		fmt.Println(b.BlockNum, b.Block.BlockId)
+//	}
+//	if err := stream.Err(); err != nil && !errors.Is(err, context.Canceled) {
+//		log.Fatal(err)
+//	}
+func (a *API) StreamBlocks(ctx context.Context, from uint, mode StreamMode, opts ...StreamOption) *BlockStream {
+	cfg := defaultStreamConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ch := make(chan *WrapBlock, cfg.buffer)
+	s := &BlockStream{C: ch, streamState: newStreamState(), acks: &acker{cp: cfg.checkpointer}}
+	go func() {
+		from, _, err := s.acks.resume(ctx, from)
+		if err != nil {
+			close(ch)
+			s.finish(err)
+			return
+		}
+		err = a.follow(ctx, from, mode, cfg, func(next, end uint) (uint, error) {
+			blocks, err := a.GetBlocksContext(ctx, next, end)
+			if err != nil {
+				return 0, err
+			}
+			var delivered uint
+			for _, b := range blocks {
+				if b.Block == nil || b.Block.BlockId == "" {
+					// The node reported the block but cannot serve it yet;
+					// pick it up again on the next poll.
+					break
+				}
+				s.acks.track(b, Checkpoint{BlockNum: b.BlockNum, OpIndex: AllOps})
+				select {
+				case ch <- b:
+				case <-ctx.Done():
+					return delivered, ctx.Err()
+				}
+				delivered++
+			}
+			return delivered, nil
+		})
+		close(ch)
+		s.finish(err)
+	}()
+	return s
+}
+
+// follow drives a stream: it polls the node for the newest block mode allows,
+// and hands every range of at most cfg.catchUp new blocks to round, which
+// delivers them and reports how many it delivered. A short count means the
+// rest is not available yet, so follow waits for the next poll.
+func (a *API) follow(ctx context.Context, next uint, mode StreamMode, cfg streamConfig, round func(next, end uint) (uint, error)) error {
+	for {
+		target, err := a.streamTarget(ctx, mode)
+		if err != nil {
+			return err
+		}
+		if next == 0 {
+			next = target
+		}
+		if next == 0 {
+			next = 1 // block numbers start at 1
+		}
+		for next <= target {
+			end := target + 1
+			if end-next > uint(cfg.catchUp) {
+				end = next + uint(cfg.catchUp)
+			}
+			delivered, err := round(next, end)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return errors.Wrap(err, "failed to stream blocks")
+			}
+			next += delivered
+			if next < end {
+				break
+			}
+		}
+		if err := sleepContext(ctx, cfg.interval); err != nil {
+			return err
+		}
+	}
+}
+
+// streamTarget returns the newest block number the stream may deliver.
+func (a *API) streamTarget(ctx context.Context, mode StreamMode) (uint, error) {
+	dgp, err := a.GetDynamicGlobalPropertiesContext(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, errors.Wrap(err, "failed to stream blocks")
+	}
+	if mode == StreamIrreversible {
+		return uint(dgp.LastIrreversibleBlockNum), nil
+	}
+	return uint(dgp.HeadBlockNumber), nil
+}
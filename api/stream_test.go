@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// standInChain is a steemtest node whose blocks from 2 on each hold a
+// transfer, a vote, an operation type unknown to steemutil and a
+// producer_reward virtual op. Its transport counts concurrent get_block
+// requests and can fail one block with a transport error.
+type standInChain struct {
+	*steemtest.Node
+	failAt   uint32 // get_block for this number fails with a transport error
+	inFlight int32
+	maxInFl  int32
+}
+
+// newStandInChain returns a chain at block head whose last irreversible
+// block trails the head by lag blocks.
+func newStandInChain(t *testing.T, head, lag uint32) *standInChain {
+	t.Helper()
+	c := &standInChain{Node: steemtest.NewNode(steemtest.WithIrreversibleLag(lag))}
+	t.Cleanup(c.Close)
+	c.advance(head - 1)
+	return c
+}
+
+// advance produces n blocks.
+func (c *standInChain) advance(n uint32) {
+	for i := uint32(0); i < n; i++ {
+		num := c.HeadBlockNum() + 1
+		c.PushTransaction(steemtest.Op{Type: "transfer", Value: map[string]interface{}{"from": "alice", "to": "bob", "amount": "1.000 STEEM", "memo": fmt.Sprint(num)}})
+		c.PushTransaction(steemtest.Op{Type: "vote", Value: map[string]interface{}{"voter": "carol", "author": "dave", "permlink": "p", "weight": 10000}})
+		c.PushTransaction(steemtest.Op{Type: "future_operation", Value: map[string]interface{}{"account": "erin"}})
+		c.PushVirtualOp(steemtest.Op{Type: "producer_reward", Value: map[string]interface{}{"producer": "witness1", "vesting_shares": "1.000000 VESTS"}})
+		c.AdvanceBlocks(1)
+	}
+}
+
+func (c *standInChain) transport() Transport {
+	node := NewHTTPTransport(c.URL, nil)
+	return funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		if req.Method != "condenser_api.get_block" {
+			return node.Send(ctx, req)
+		}
+		n := atomic.AddInt32(&c.inFlight, 1)
+		defer atomic.AddInt32(&c.inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&c.maxInFl)
+			if n <= max || atomic.CompareAndSwapInt32(&c.maxInFl, max, n) {
+				break
+			}
+		}
+		if num := uint32(req.Params.([]interface{})[0].(uint)); c.failAt != 0 && num == c.failAt {
+			// Fail late, once the requests for the blocks before it are done.
+			time.Sleep(20 * time.Millisecond)
+			return nil, errors.New("connection reset")
+		}
+		return node.Send(ctx, req)
+	})
+}
+
+func collectBlocks(t *testing.T, stream *BlockStream, n int) []*WrapBlock {
+	t.Helper()
+	var blocks []*WrapBlock
+	timeout := time.After(5 * time.Second)
+	for len(blocks) < n {
+		select {
+		case b, ok := <-stream.C:
+			if !ok {
+				t.Fatalf("stream stopped after %d blocks: %v", len(blocks), stream.Err())
+			}
+			blocks = append(blocks, b)
+		case <-timeout:
+			t.Fatalf("timed out after %d blocks", len(blocks))
+		}
+	}
+	return blocks
+}
+
+func assertInOrder(t *testing.T, chain *standInChain, blocks []*WrapBlock, from uint) {
+	t.Helper()
+	for i, b := range blocks {
+		want, _ := chain.Block(uint32(from) + uint32(i))
+		if b.BlockNum != uint(want.Num) || b.Block.BlockId != want.ID {
+			t.Fatalf("position %d: got block %d (%s), want %d (%s)", i, b.BlockNum, b.Block.BlockId, want.Num, want.ID)
+		}
+	}
+}
+
+func TestStreamBlocks_CatchUpThenFollowHead(t *testing.T) {
+	chain := newStandInChain(t, 100, 0)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := api.StreamBlocks(ctx, 1, StreamHead, WithStreamInterval(5*time.Millisecond), WithStreamCatchUp(20))
+	go func() {
+		for ctx.Err() == nil {
+			time.Sleep(5 * time.Millisecond)
+			chain.advance(1)
+		}
+	}()
+
+	blocks := collectBlocks(t, stream, 130)
+	assertInOrder(t, chain, blocks, 1)
+	if max := atomic.LoadInt32(&chain.maxInFl); max > 20 {
+		t.Errorf("catch-up exceeded its bound: %d concurrent requests", max)
+	}
+
+	cancel()
+	for range stream.C {
+	}
+	if err := stream.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestStreamBlocks_IrreversibleMode(t *testing.T) {
+	chain := newStandInChain(t, 50, 20)
+	api := NewAPI("http://node", WithTransport(chain.transport()))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := api.StreamBlocks(ctx, 0, StreamIrreversible, WithStreamInterval(5*time.Millisecond))
+	first := collectBlocks(t, stream, 1)
+	if first[0].BlockNum != 30 {
+		t.Fatalf("expected to start at the last irreversible block 30, got %d", first[0].BlockNum)
+	}
+
+	select {
+	case b := <-stream.C:
+		t.Fatalf("block %d delivered before it became irreversible", b.BlockNum)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	chain.advance(5)
+	assertInOrder(t, chain, collectBlocks(t, stream, 5), 31)
+}
+
+func TestStreamBlocks_StopsOnPersistentError(t *testing.T) {
+	chain := newStandInChain(t, 10, 0)
+	chain.failAt = 7
+	api := NewAPI("http://node", WithTransport(chain.transport()), fastRetry(1))
+
+	stream := api.StreamBlocks(context.Background(), 1, StreamHead, WithStreamInterval(time.Millisecond))
+	var got []*WrapBlock
+	for b := range stream.C {
+		got = append(got, b)
+	}
+	if err := stream.Err(); err == nil || !strings.Contains(err.Error(), "get block {7} error") {
+		t.Fatalf("expected block 7 to stop the stream, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("the failed round must not be delivered, got %d block(s)", len(got))
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RPCRequest is a single JSON-RPC 2.0 request envelope handed to a Transport.
+//
+// Params is an interface{} rather than []interface{} so that a Transport does
+// not need to care about the shape the target API expects; the API layer
+// decides what goes on the wire.
+type RPCRequest struct {
+	JsonRpc string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// RPCResponse is a single JSON-RPC 2.0 response envelope returned by a
+// Transport. Result and Error are kept as raw JSON so the API layer decodes
+// them exactly once, into whatever type the caller asked for.
+type RPCResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// HasError reports whether the node returned a JSON-RPC error object.
+func (r *RPCResponse) HasError() bool {
+	return len(r.Error) > 0 && string(r.Error) != "null"
+}
+
+// HTTPStatusError is returned by HTTPTransport when the node answers with a
+// status other than 200 OK.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("failed to response(http code): %v", e.StatusCode)
+}
+
+// Transport sends a JSON-RPC request to a Steem node and returns its response.
+//
+// Implementations must honour ctx cancellation and must only return a non-nil
+// error for failures to obtain a response (connection errors, bad HTTP status,
+// undecodable body). A JSON-RPC error object from the node is a successful
+// round trip and is reported through RPCResponse.Error instead.
+//
+// The default implementation is HTTPTransport. Custom transports (mocks,
+// recorders, proxies, instrumented clients) are installed with WithTransport.
+type Transport interface {
+	Send(ctx context.Context, req *RPCRequest) (*RPCResponse, error)
+}
+
+// BatchTransport is implemented by transports that can send several requests
+// as one JSON-RPC 2.0 batch array. Responses may come back in any order; the
+// caller matches them to requests by id.
+//
+// Transports without batch support still work with Batch: their requests are
+// sent concurrently, one Send each.
+type BatchTransport interface {
+	Transport
+	SendBatch(ctx context.Context, reqs []*RPCRequest) ([]*RPCResponse, error)
+}
+
+// HTTPTransport is the default Transport: one HTTP POST per request, matching
+// the wire behaviour of steemutil's jsonrpc2 client.
+type HTTPTransport struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPTransport creates an HTTPTransport that posts to url. If client is
+// nil, a client with a 30-second timeout is used (the same per-request timeout
+// steemutil's jsonrpc2 client uses); a context deadline shorter than the
+// client timeout wins.
+func NewHTTPTransport(url string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPTransport{
+		url:    url,
+		client: client,
+	}
+}
+
+// Send posts req to the node and decodes the response envelope.
+func (t *HTTPTransport) Send(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	setContextHeaders(ctx, httpReq)
+
+	res, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: res.StatusCode}
+	}
+
+	resp := &RPCResponse{}
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SendBatch posts reqs as a single JSON-RPC batch array.
+func (t *HTTPTransport) SendBatch(ctx context.Context, reqs []*RPCRequest) ([]*RPCResponse, error) {
+	body, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	setContextHeaders(ctx, httpReq)
+
+	res, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: res.StatusCode}
+	}
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var resps []*RPCResponse
+	if err := json.Unmarshal(raw, &resps); err != nil {
+		// Nodes that reject the whole batch answer with a single error object.
+		single := &RPCResponse{}
+		if json.Unmarshal(raw, single) == nil && single.HasError() {
+			return nil, errors.Errorf("batch rejected: %s", single.Error)
+		}
+		return nil, err
+	}
+	return resps, nil
+}
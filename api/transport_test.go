@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// funcTransport adapts a function to the Transport interface so tests can
+// stand in for a node without an HTTP server.
+type funcTransport func(ctx context.Context, req *RPCRequest) (*RPCResponse, error)
+
+func (f funcTransport) Send(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	return f(ctx, req)
+}
+
+func TestWithTransport(t *testing.T) {
+	var seen []*RPCRequest
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		seen = append(seen, req)
+		return &RPCResponse{
+			JsonRpc: "2.0",
+			ID:      req.ID,
+			Result:  json.RawMessage(`{"account":"alice","follower_count":3,"following_count":4}`),
+		}, nil
+	})
+	api := NewAPI("http://unused.invalid", WithTransport(transport))
+
+	fc, err := api.GetFollowCount("alice")
+	if err != nil {
+		t.Fatalf("GetFollowCount failed: %v", err)
+	}
+	if fc.FollowerCount != 3 || fc.FollowingCount != 4 {
+		t.Errorf("unexpected follow count: %+v", fc)
+	}
+	if len(seen) != 1 {
+		t.Fatalf("expected 1 request through the transport, got %d", len(seen))
+	}
+	if seen[0].Method != "condenser_api.get_follow_count" || seen[0].JsonRpc != "2.0" {
+		t.Errorf("unexpected request envelope: %+v", seen[0])
+	}
+}
+
+func TestWithTransport_UniqueIDs(t *testing.T) {
+	ids := map[uint64]bool{}
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		ids[req.ID] = true
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`[]`)}, nil
+	})
+	api := NewAPI("http://unused.invalid", WithTransport(transport))
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.LookupAccounts("a", 1); err != nil {
+			t.Fatalf("LookupAccounts failed: %v", err)
+		}
+	}
+	if len(ids) != 3 {
+		t.Errorf("expected 3 distinct request ids, got %v", ids)
+	}
+}
+
+func TestWithTransport_RPCError(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{
+			ID:    req.ID,
+			Error: json.RawMessage(`{"code":-32602,"message":"Invalid parameters"}`),
+		}, nil
+	})
+	api := NewAPI("http://unused.invalid", WithTransport(transport))
+
+	_, err := api.Call("condenser_api", "get_block", []interface{}{1})
+	if err == nil {
+		t.Fatal("expected RPC error, got nil")
+	}
+	if !strings.Contains(err.Error(), "Invalid parameters") {
+		t.Errorf("expected node message in error, got %v", err)
+	}
+}
+
+func TestHTTPTransport_StatusError(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.FailHTTP(http.StatusBadGateway, 1)
+
+	_, err := NewHTTPTransport(node.URL, nil).Send(context.Background(), &RPCRequest{
+		JsonRpc: "2.0",
+		ID:      1,
+		Method:  "condenser_api.get_config",
+		Params:  []interface{}{},
+	})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("expected http 502 error, got %v", err)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	node := steemtest.NewNode()
+	defer node.Close()
+	node.AddAccount("alice", nil)
+	var used bool
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	api := NewAPI(node.URL, WithHTTPClient(client))
+
+	if _, err := api.LookupAccounts("a", 1); err != nil {
+		t.Fatalf("LookupAccounts failed: %v", err)
+	}
+	if !used {
+		t.Error("expected the custom http.Client to be used")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,33 @@
+package api
+
+import "context"
+
+// CallTyped calls apiName.method with positional params and decodes the
+// result into a new T straight from the response bytes.
+//
+//	props, err := api.CallTyped[protocolapi.DynamicGlobalProperties](
+//		ctx, a, "condenser_api", "get_dynamic_global_properties", nil)
+//
+// It is a function rather than a method because Go methods cannot have type
+// parameters. Errors are the same as CallContext's; an absent or null result
+// yields the zero T.
+func CallTyped[T any](ctx context.Context, a *API, apiName, method string, params []interface{}) (T, error) {
+	var result T
+	if params == nil {
+		params = []interface{}{}
+	}
+	rpcResponse, err := a.call(ctx, apiName, method, params)
+	if err != nil {
+		return result, err
+	}
+	err = decodeResult(rpcResponse.Result, &result)
+	return result, err
+}
+
+// CallNamedTyped is CallTyped for appbase APIs that take a named-object
+// params body (see CallNamed); nil params sends {}.
+func CallNamedTyped[T any](ctx context.Context, a *API, apiName, method string, params interface{}) (T, error) {
+	var result T
+	err := a.CallNamedContext(ctx, apiName, method, params, &result)
+	return result, err
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func rawResultTransport(result string) funcTransport {
+	return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(result)}, nil
+	}
+}
+
+func TestCallTyped_DecodesResult(t *testing.T) {
+	api := NewAPI("http://node", WithTransport(rawResultTransport(`["alice","bob"]`)))
+
+	names, err := CallTyped[[]string](context.Background(), api, "condenser_api", "lookup_accounts", []interface{}{"a", 2})
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if len(names) != 2 || names[1] != "bob" {
+		t.Errorf("unexpected result: %v", names)
+	}
+}
+
+// TestCallTyped_KeepsIntegerPrecision guards against decoding through
+// interface{}, which turns large integers into float64.
+func TestCallTyped_KeepsIntegerPrecision(t *testing.T) {
+	api := NewAPI("http://node", WithTransport(rawResultTransport(`{"reputation":9007199254740993}`)))
+
+	got, err := CallTyped[struct {
+		Reputation int64 `json:"reputation"`
+	}](context.Background(), api, "condenser_api", "get_account_reputations", nil)
+	if err != nil {
+		t.Fatalf("CallTyped failed: %v", err)
+	}
+	if got.Reputation != 9007199254740993 {
+		t.Errorf("lost precision: %d", got.Reputation)
+	}
+
+	var viaResult struct {
+		Reputation int64 `json:"reputation"`
+	}
+	if err := api.CallWithResult("condenser_api", "get_account_reputations", nil, &viaResult); err != nil {
+		t.Fatalf("CallWithResult failed: %v", err)
+	}
+	if viaResult.Reputation != 9007199254740993 {
+		t.Errorf("CallWithResult lost precision: %d", viaResult.Reputation)
+	}
+}
+
+func TestCallTyped_NullResult(t *testing.T) {
+	api := NewAPI("http://node", WithTransport(rawResultTransport(`null`)))
+
+	block, err := CallTyped[*struct{}](context.Background(), api, "condenser_api", "get_block", []interface{}{1 << 30})
+	if err != nil || block != nil {
+		t.Errorf("expected nil block and no error, got %v, %v", block, err)
+	}
+}
+
+func TestCallTyped_RPCError(t *testing.T) {
+	api := NewAPI("http://node", WithTransport(rpcErrNode(`{"code":-32000,"message":"Assert Exception: unknown account"}`)))
+
+	_, err := CallTyped[[]string](context.Background(), api, "condenser_api", "get_accounts", nil)
+	if !errors.Is(err, ErrUnknownAccount) {
+		t.Errorf("expected ErrUnknownAccount, got %v", err)
+	}
+}
+
+func TestCallNamedTyped(t *testing.T) {
+	var sent interface{}
+	api := NewAPI("http://node", WithTransport(funcTransport(func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+		sent = req.Params
+		return &RPCResponse{ID: req.ID, Result: json.RawMessage(`{"rc_accounts":[{"account":"alice"}]}`)}, nil
+	})))
+
+	type rcAccounts struct {
+		RCAccounts []struct {
+			Account string `json:"account"`
+		} `json:"rc_accounts"`
+	}
+	got, err := CallNamedTyped[rcAccounts](context.Background(), api, "rc_api", "find_rc_accounts", map[string]interface{}{"accounts": []string{"alice"}})
+	if err != nil {
+		t.Fatalf("CallNamedTyped failed: %v", err)
+	}
+	if len(got.RCAccounts) != 1 || got.RCAccounts[0].Account != "alice" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+	if _, ok := sent.(map[string]interface{}); !ok {
+		t.Errorf("expected object params, got %T", sent)
+	}
+}
+
+func BenchmarkAPI_GetBlock(b *testing.B) {
+	block := `{"previous":"0000000000000000000000000000000000000000","timestamp":"2016-03-24T16:05:00","witness":"initminer","transaction_merkle_root":"0000000000000000000000000000000000000000","extensions":[],"witness_signature":"","transactions":[],"block_id":"0000000109833ce528d5bbfb3f6225b39ee10086","signing_key":"","transaction_ids":[]}`
+	api := NewAPI("http://node", WithTransport(rawResultTransport(block)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := api.GetBlock(1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
 	protocolapi "github.com/steemit/steemutil/protocol/api"
 	"github.com/steemit/steemutil/rpc"
@@ -22,6 +24,12 @@ import (
 // from steemutil's rpc.Validate (envelope/freshness) or rpc.VerifySignedRpc
 // (cryptographic check).
 func (a *API) VerifySignedRequest(signedReq *rpc.SignedRequest) (params interface{}, account string, err error) {
+	return a.VerifySignedRequestContext(context.Background(), signedReq)
+}
+
+// VerifySignedRequestContext is like VerifySignedRequest but binds ctx to the
+// get_accounts lookup, so a cancelled or expired ctx aborts verification.
+func (a *API) VerifySignedRequestContext(ctx context.Context, signedReq *rpc.SignedRequest) (params interface{}, account string, err error) {
 	account = signedReq.Params.Signed.Account
 
 	// accountFetcher implements rpc.AccountFetcher by looking up the account's
@@ -31,7 +39,7 @@ func (a *API) VerifySignedRequest(signedReq *rpc.SignedRequest) (params interfac
 	// A not-found account (or any get_accounts error) is surfaced by
 	// VerifySignedRpc as "No such account".
 	accountFetcher := func(name string) (protocolapi.Authority, error) {
-		accts, ferr := a.GetAccounts([]string{name})
+		accts, ferr := a.GetAccountsContext(ctx, []string{name})
 		if ferr != nil {
 			return protocolapi.Authority{}, errors.Wrapf(ferr, "failed to fetch account %s", name)
 		}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/steemit/steemgosdk/steemtest"
 	"github.com/steemit/steemutil/rpc"
 )
 
@@ -14,8 +15,8 @@ import (
 //	WIF       5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma
 //	PublicKey STM7jNh5ejQoqHqWcGWFJ1v4F5CzsG3EiBuz1VooCng1cH5QpJD27
 //
-// The mock get_accounts returns this pubkey as the account's single posting
-// key_auth with weight 1 (clearing the weight_threshold of 1). rpc.Sign signs
+// The node serves this pubkey as the account's single posting key_auth with
+// weight 1 (clearing the weight_threshold of 1). rpc.Sign signs
 // with the matching WIF, so VerifySignedRequest must succeed.
 const (
 	g1TestWIF    = "5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma"
@@ -23,33 +24,20 @@ const (
 	g1TestAcct   = "testaccount"
 )
 
-// mockAccountResponse builds a get_accounts response for one account with a
-// single posting key_auth. weightThreshold and keyWeight control whether the
-// key clears the threshold; keyAuths controls the count (for multisig tests).
-func mockAccountResponse(name, pubKey string, weightThreshold uint32, keyWeight int, keyAuths int) []map[string]interface{} {
-	auths := make([]interface{}, 0, keyAuths)
-	for i := 0; i < keyAuths; i++ {
-		// Use the same pubKey for all entries; count is what matters here.
-		// For the single-key happy path keyAuths==1.
-		auths = append(auths, []interface{}{pubKey, keyWeight})
-	}
-	return []map[string]interface{}{
-		{
-			"name": name,
-			"posting": map[string]interface{}{
-				"weight_threshold": weightThreshold,
-				"account_auths":    []interface{}{},
-				"key_auths":        auths,
-			},
-		},
+// verifyAPI returns an API on a node serving account name with the given
+// posting authority, or no account at all when posting is nil.
+func verifyAPI(t *testing.T, name string, posting map[string]interface{}) *API {
+	t.Helper()
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	if posting != nil {
+		node.AddAccount(name, map[string]interface{}{"posting": posting})
 	}
+	return NewAPI(node.URL)
 }
 
 func TestVerifySignedRequest_Success(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": mockAccountResponse(g1TestAcct, g1TestPubKey, 1, 1, 1),
-	})
-	api := NewAPI(server.URL)
+	api := verifyAPI(t, g1TestAcct, steemtest.KeyAuthority(g1TestPubKey))
 
 	// Sign a request with the matching WIF. rpc.Sign uses the current time, so
 	// the 60s freshness window is satisfied when we validate immediately after.
@@ -89,10 +77,7 @@ func TestVerifySignedRequest_Success(t *testing.T) {
 }
 
 func TestVerifySignedRequest_TamperedSignature(t *testing.T) {
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": mockAccountResponse(g1TestAcct, g1TestPubKey, 1, 1, 1),
-	})
-	api := NewAPI(server.URL)
+	api := verifyAPI(t, g1TestAcct, steemtest.KeyAuthority(g1TestPubKey))
 
 	signedReq, err := rpc.Sign(
 		&rpc.RpcRequest{
@@ -134,10 +119,7 @@ func TestVerifySignedRequest_WrongAccount(t *testing.T) {
 	// get_accounts returns a DIFFERENT posting pubkey than the one the WIF
 	// corresponds to, so recovery won't match.
 	wrongPub := "STM7W7ACQDZJZ6rZGKeT9auipnSiSxFxJ4k71QXmrhY9HbvYsNnQ2"
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": mockAccountResponse(g1TestAcct, wrongPub, 1, 1, 1),
-	})
-	api := NewAPI(server.URL)
+	api := verifyAPI(t, g1TestAcct, steemtest.KeyAuthority(wrongPub))
 
 	signedReq, err := rpc.Sign(
 		&rpc.RpcRequest{
@@ -161,10 +143,7 @@ func TestVerifySignedRequest_WrongAccount(t *testing.T) {
 func TestVerifySignedRequest_MultisigRejected(t *testing.T) {
 	// Steem's verifier only supports accounts with a single posting key.
 	// Two key_auths -> "Unsupported posting key configuration".
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": mockAccountResponse(g1TestAcct, g1TestPubKey, 1, 1, 2),
-	})
-	api := NewAPI(server.URL)
+	api := verifyAPI(t, g1TestAcct, steemtest.KeyAuthority(g1TestPubKey, "STM7W7ACQDZJZ6rZGKeT9auipnSiSxFxJ4k71QXmrhY9HbvYsNnQ2"))
 
 	signedReq, err := rpc.Sign(
 		&rpc.RpcRequest{
@@ -191,10 +170,7 @@ func TestVerifySignedRequest_MultisigRejected(t *testing.T) {
 func TestVerifySignedRequest_AccountNotFound(t *testing.T) {
 	// get_accounts returns an empty array -> fetcher returns "no such account",
 	// which VerifySignedRpc reports as "No such account".
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": []interface{}{},
-	})
-	api := NewAPI(server.URL)
+	api := verifyAPI(t, g1TestAcct, nil)
 
 	signedReq, err := rpc.Sign(
 		&rpc.RpcRequest{
@@ -220,13 +196,10 @@ func TestVerifySignedRequest_AccountNotFound(t *testing.T) {
 
 func TestVerifySignedRequest_ShortAccountName(t *testing.T) {
 	// Account names shorter than 3 chars are rejected by VerifySignedRpc with
-	// "Invalid account name". No get_accounts mock needed (fetcher is never
-	// reached), but provide one anyway so a missing-mock error doesn't mask
-	// the real assertion if the check ordering ever changes.
-	server := mockRPCServer(t, map[string]interface{}{
-		"condenser_api.get_accounts": mockAccountResponse("ab", g1TestPubKey, 1, 1, 1),
-	})
-	api := NewAPI(server.URL)
+	// "Invalid account name". The fetcher is never reached, but the node
+	// serves the account anyway so a missing account doesn't mask the real
+	// assertion if the check ordering ever changes.
+	api := verifyAPI(t, "ab", steemtest.KeyAuthority(g1TestPubKey))
 
 	signedReq, err := rpc.Sign(
 		&rpc.RpcRequest{
@@ -0,0 +1,571 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTransportClosed is returned by a Transport after Close has been called.
+var ErrTransportClosed = errors.New("transport is closed")
+
+// WebSocketTransport is a Transport that keeps a single WebSocket connection
+// to a steemd node open and multiplexes concurrent requests over it.
+//
+// Each request is rewritten with a transport-unique JSON-RPC id before it is
+// written, and the response is routed back to its caller by that id, so
+// several API instances may share one WebSocketTransport. When the connection
+// drops, every in-flight request fails and the next Send re-dials with
+// exponential backoff.
+//
+// NewAPI selects this transport automatically for ws:// and wss:// URLs.
+// Signed calls are rejected on it (see API.SignedCall).
+type WebSocketTransport struct {
+	url       string
+	tlsConfig *tls.Config
+
+	// Reconnect backoff: the first re-dial waits minBackoff, doubling up to
+	// maxBackoff, for at most dialAttempts attempts per Send.
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	dialAttempts int
+
+	nextID uint64 // wire id counter, accessed atomically
+
+	dialMu sync.Mutex // serializes dialing so concurrent Sends share one connection
+	mu     sync.Mutex // guards conn and closed
+	conn   *wsConn
+	closed bool
+}
+
+// WebSocketOption configures a WebSocketTransport.
+type WebSocketOption func(*WebSocketTransport)
+
+// WithWebSocketBackoff sets the reconnect backoff: the first retry waits min,
+// doubling up to max, for at most attempts dial attempts per request.
+func WithWebSocketBackoff(min, max time.Duration, attempts int) WebSocketOption {
+	return func(t *WebSocketTransport) {
+		t.minBackoff = min
+		t.maxBackoff = max
+		t.dialAttempts = attempts
+	}
+}
+
+// WithWebSocketTLSConfig sets the TLS configuration used for wss:// URLs.
+func WithWebSocketTLSConfig(cfg *tls.Config) WebSocketOption {
+	return func(t *WebSocketTransport) {
+		t.tlsConfig = cfg
+	}
+}
+
+// NewWebSocketTransport creates a WebSocketTransport for a ws:// or wss://
+// URL. The connection is opened lazily on the first Send.
+func NewWebSocketTransport(rawURL string, opts ...WebSocketOption) *WebSocketTransport {
+	t := &WebSocketTransport{
+		url:          rawURL,
+		minBackoff:   100 * time.Millisecond,
+		maxBackoff:   5 * time.Second,
+		dialAttempts: 5,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Send writes req over the shared connection and waits for the response with
+// the matching id, or for ctx to be done.
+func (t *WebSocketTransport) Send(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	conn, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wire := *req
+	wire.ID = atomic.AddUint64(&t.nextID, 1)
+	body, err := json.Marshal(&wire)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.register(wire.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.unregister(wire.ID)
+
+	if err := conn.writeFrame(wsOpText, body); err != nil {
+		conn.fail(err)
+		return nil, errors.Wrap(err, "failed to write websocket request")
+	}
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, res.err
+		}
+		res.resp.ID = req.ID
+		return res.resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the connection and fails any in-flight requests. Subsequent
+// calls to Send return ErrTransportClosed.
+func (t *WebSocketTransport) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	conn := t.conn
+	t.conn = nil
+	t.mu.Unlock()
+
+	if conn != nil {
+		conn.writeFrame(wsOpClose, nil)
+		conn.fail(ErrTransportClosed)
+	}
+	return nil
+}
+
+// connect returns the live connection, dialing (with backoff) if there is none.
+func (t *WebSocketTransport) connect(ctx context.Context) (*wsConn, error) {
+	if conn, err := t.current(); conn != nil || err != nil {
+		return conn, err
+	}
+
+	t.dialMu.Lock()
+	defer t.dialMu.Unlock()
+
+	// Another Send may have dialed while we waited for dialMu.
+	if conn, err := t.current(); conn != nil || err != nil {
+		return conn, err
+	}
+
+	backoff := t.minBackoff
+	for attempt := 1; ; attempt++ {
+		conn, err := dialWebSocket(ctx, t.url, t.tlsConfig)
+		if err == nil {
+			t.mu.Lock()
+			if t.closed {
+				t.mu.Unlock()
+				conn.fail(ErrTransportClosed)
+				return nil, ErrTransportClosed
+			}
+			t.conn = conn
+			t.mu.Unlock()
+			go t.readLoop(conn)
+			return conn, nil
+		}
+		if attempt >= t.dialAttempts || ctx.Err() != nil {
+			return nil, errors.Wrapf(err, "failed to connect to %s after %d attempt(s)", t.url, attempt)
+		}
+
+		select {
+		case <-time.After(jitter(backoff)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+		if backoff > t.maxBackoff {
+			backoff = t.maxBackoff
+		}
+	}
+}
+
+// current returns the live connection, nil if a dial is needed, or
+// ErrTransportClosed.
+func (t *WebSocketTransport) current() (*wsConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrTransportClosed
+	}
+	if t.conn != nil && !t.conn.dead() {
+		return t.conn, nil
+	}
+	t.conn = nil
+	return nil, nil
+}
+
+// readLoop dispatches responses to their waiting callers until the
+// connection fails.
+func (t *WebSocketTransport) readLoop(conn *wsConn) {
+	for {
+		payload, err := conn.readMessage()
+		if err != nil {
+			conn.fail(errors.Wrap(err, "websocket connection lost"))
+			return
+		}
+		var resp RPCResponse
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			// A frame we cannot attribute to a request; skip it rather than
+			// tearing down every in-flight call.
+			continue
+		}
+		conn.deliver(resp.ID, &resp)
+	}
+}
+
+// jitter returns d randomized into [d/2, d) so reconnecting clients spread out.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := int64(d / 2)
+	n, err := rand.Int(rand.Reader, big.NewInt(half))
+	if err != nil {
+		return d
+	}
+	return time.Duration(half + n.Int64())
+}
+
+// ---------------------------------------------------------------------------
+// Minimal RFC 6455 client connection.
+//
+// Only what a JSON-RPC client needs is implemented: text messages (with
+// fragmentation), ping/pong and close. No extensions or subprotocols are
+// negotiated.
+// ---------------------------------------------------------------------------
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	// wsMaxMessageSize bounds a single assembled message; large get_block
+	// responses are well under this.
+	wsMaxMessageSize = 64 << 20
+
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+type wsResult struct {
+	resp *RPCResponse
+	err  error
+}
+
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	// mask is true for client connections (RFC 6455 requires clients to
+	// mask every frame and servers to never mask).
+	mask bool
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex // guards pending and err
+	pending map[uint64]chan wsResult
+	err     error
+}
+
+func newWSConn(conn net.Conn, br *bufio.Reader, mask bool) *wsConn {
+	return &wsConn{
+		conn:    conn,
+		br:      br,
+		mask:    mask,
+		pending: make(map[uint64]chan wsResult),
+	}
+}
+
+func (c *wsConn) dead() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err != nil
+}
+
+func (c *wsConn) register(id uint64) (<-chan wsResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	ch := make(chan wsResult, 1)
+	c.pending[id] = ch
+	return ch, nil
+}
+
+func (c *wsConn) unregister(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *wsConn) deliver(id uint64, resp *RPCResponse) {
+	c.mu.Lock()
+	ch, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		ch <- wsResult{resp: resp}
+	}
+}
+
+// fail marks the connection dead, closes it and fails every pending request.
+func (c *wsConn) fail(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	pending := c.pending
+	c.pending = make(map[uint64]chan wsResult)
+	c.mu.Unlock()
+
+	c.conn.Close()
+	for _, ch := range pending {
+		ch <- wsResult{err: err}
+	}
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeWSFrame(c.conn, opcode, payload, c.mask)
+}
+
+// readMessage returns the next complete text or binary message, answering
+// pings and surfacing close frames as io.EOF along the way.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := readWSFrame(c.br)
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			c.writeFrame(wsOpClose, nil)
+			return nil, io.EOF
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			if len(message)+len(payload) > wsMaxMessageSize {
+				return nil, errors.New("websocket message too large")
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, errors.Errorf("unexpected websocket opcode %#x", opcode)
+		}
+	}
+}
+
+// dialWebSocket opens a TCP (or TLS) connection and performs the opening
+// handshake. ctx bounds both the dial and the handshake.
+func dialWebSocket(ctx context.Context, rawURL string, tlsConfig *tls.Config) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid websocket url")
+	}
+	var secure bool
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		secure = true
+	default:
+		return nil, errors.Errorf("unsupported websocket scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if secure {
+		cfg := &tls.Config{}
+		if tlsConfig != nil {
+			cfg = tlsConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	br, err := wsClientHandshake(conn, u)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return newWSConn(conn, br, true), nil
+}
+
+func wsClientHandshake(conn net.Conn, u *url.URL) (*bufio.Reader, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	path := u.RequestURI()
+	if path == "" {
+		path = "/"
+	}
+	req := fmt.Sprintf("GET %s HTTP/1.1\r\n"+
+		"Host: %s\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n", path, u.Host, key)
+	if _, err := io.WriteString(conn, req); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read websocket handshake response")
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return nil, errors.Errorf("websocket handshake failed (http code): %v", res.StatusCode)
+	}
+	if !strings.EqualFold(res.Header.Get("Upgrade"), "websocket") {
+		return nil, errors.New("websocket handshake failed: missing Upgrade header")
+	}
+	if res.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		return nil, errors.New("websocket handshake failed: bad Sec-WebSocket-Accept")
+	}
+	return br, nil
+}
+
+// wsAcceptKey computes the Sec-WebSocket-Accept value for a handshake key.
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func writeWSFrame(w io.Writer, opcode byte, payload []byte, mask bool) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | opcode // FIN + opcode
+	var maskBit byte
+	if mask {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = maskBit | byte(n)
+	case n <= 0xFFFF:
+		header[1] = maskBit | 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header[1] = maskBit | 127
+		header = append(header, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+
+	if mask {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		header = append(header, key[:]...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ key[i%4]
+		}
+		payload = masked
+	}
+
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func readWSFrame(r *bufio.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(r, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessageSize {
+		err = errors.New("websocket frame too large")
+		return
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err = io.ReadFull(r, key[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+	return
+}
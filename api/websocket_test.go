@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// wsStandIn is an in-process WebSocket stand-in for a steemd node. Each
+// request is answered on its own goroutine by handle, so responses can come
+// back out of order; a nil result from handle means "never answer".
+type wsStandIn struct {
+	server      *httptest.Server
+	connections int32
+	// dropAfter closes each connection after that many responses (0 = never).
+	dropAfter int32
+	handle    func(req *RPCRequest) (result interface{}, delay time.Duration)
+}
+
+func newWSStandIn(t *testing.T, handle func(req *RPCRequest) (interface{}, time.Duration)) *wsStandIn {
+	t.Helper()
+	s := &wsStandIn{handle: handle}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *wsStandIn) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *wsStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+		return
+	}
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	atomic.AddInt32(&s.connections, 1)
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", wsAcceptKey(key))
+	rw.Flush()
+
+	var (
+		writeMu sync.Mutex
+		sent    int32
+	)
+	for {
+		_, opcode, payload, err := readWSFrame(rw.Reader)
+		if err != nil || opcode == wsOpClose {
+			return
+		}
+		var req RPCRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return
+		}
+		go func() {
+			result, delay := s.handle(&req)
+			if result == nil {
+				return
+			}
+			time.Sleep(delay)
+			body, _ := json.Marshal(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"result":  result,
+			})
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			writeWSFrame(conn, wsOpText, body, false)
+			if n := atomic.AddInt32(&sent, 1); s.dropAfter > 0 && n >= s.dropAfter {
+				conn.Close()
+			}
+		}()
+	}
+}
+
+func TestNewAPI_SelectsWebSocketTransport(t *testing.T) {
+	for _, url := range []string{"ws://127.0.0.1:1", "wss://127.0.0.1:1"} {
+		api := NewAPI(url)
+		if _, ok := api.transport.(*WebSocketTransport); !ok {
+			t.Errorf("expected WebSocketTransport for %s, got %T", url, api.transport)
+		}
+	}
+	if _, ok := NewAPI("https://api.steemit.com").transport.(*HTTPTransport); !ok {
+		t.Error("expected HTTPTransport for https URL")
+	}
+}
+
+func TestWebSocketTransport_PersistentConnection(t *testing.T) {
+	node := newWSStandIn(t, func(req *RPCRequest) (interface{}, time.Duration) {
+		return map[string]interface{}{"account": "alice", "follower_count": 1, "following_count": 2}, 0
+	})
+	api := NewAPI(node.URL())
+	defer api.Close()
+
+	for i := 0; i < 5; i++ {
+		fc, err := api.GetFollowCount("alice")
+		if err != nil {
+			t.Fatalf("GetFollowCount failed: %v", err)
+		}
+		if fc.FollowingCount != 2 {
+			t.Errorf("unexpected follow count: %+v", fc)
+		}
+	}
+	if n := atomic.LoadInt32(&node.connections); n != 1 {
+		t.Errorf("expected a single persistent connection, got %d", n)
+	}
+}
+
+// TestWebSocketTransport_Multiplexing fires concurrent requests that the
+// stand-in answers in reverse order, and checks each caller gets its own
+// response back.
+func TestWebSocketTransport_Multiplexing(t *testing.T) {
+	const n = 20
+	node := newWSStandIn(t, func(req *RPCRequest) (interface{}, time.Duration) {
+		var params []interface{}
+		raw, _ := json.Marshal(req.Params)
+		json.Unmarshal(raw, &params)
+		prefix := params[0].(string)
+		var i int
+		fmt.Sscanf(prefix, "name%d", &i)
+		return []string{prefix}, time.Duration(n-i) * 2 * time.Millisecond
+	})
+	api := NewAPI(node.URL())
+	defer api.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			want := fmt.Sprintf("name%d", i)
+			names, err := api.LookupAccounts(want, 1)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(names) != 1 || names[0] != want {
+				errs <- errors.Errorf("request %s got response %v", want, names)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if c := atomic.LoadInt32(&node.connections); c != 1 {
+		t.Errorf("expected all requests on one connection, got %d", c)
+	}
+}
+
+func TestWebSocketTransport_Reconnect(t *testing.T) {
+	node := newWSStandIn(t, func(req *RPCRequest) (interface{}, time.Duration) {
+		return []string{"alice"}, 0
+	})
+	node.dropAfter = 1
+	transport := NewWebSocketTransport(node.URL(), WithWebSocketBackoff(time.Millisecond, 10*time.Millisecond, 5))
+	api := NewAPI(node.URL(), WithTransport(transport))
+	defer api.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := api.LookupAccounts("a", 1); err != nil {
+			// The drop races the next request; a failed in-flight call is
+			// acceptable as long as the following one reconnects.
+			t.Logf("call %d failed during reconnect: %v", i, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := api.LookupAccounts("a", 1); err != nil {
+		t.Fatalf("expected call after reconnect to succeed, got %v", err)
+	}
+	if c := atomic.LoadInt32(&node.connections); c < 2 {
+		t.Errorf("expected the transport to reconnect, got %d connection(s)", c)
+	}
+}
+
+func TestWebSocketTransport_DialFailure(t *testing.T) {
+	transport := NewWebSocketTransport("ws://127.0.0.1:1", WithWebSocketBackoff(time.Millisecond, time.Millisecond, 2))
+	_, err := transport.Send(context.Background(), &RPCRequest{JsonRpc: "2.0", Method: "condenser_api.get_config"})
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempt(s)") {
+		t.Errorf("expected bounded dial failure, got %v", err)
+	}
+}
+
+func TestWebSocketTransport_ContextCancel(t *testing.T) {
+	node := newWSStandIn(t, func(req *RPCRequest) (interface{}, time.Duration) {
+		return nil, 0 // never answer
+	})
+	api := NewAPI(node.URL())
+	defer api.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := api.CallContext(ctx, "condenser_api", "get_config", []interface{}{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWebSocketTransport_Close(t *testing.T) {
+	node := newWSStandIn(t, func(req *RPCRequest) (interface{}, time.Duration) {
+		return []string{}, 0
+	})
+	api := NewAPI(node.URL())
+	if _, err := api.LookupAccounts("a", 1); err != nil {
+		t.Fatalf("LookupAccounts failed: %v", err)
+	}
+	api.Close()
+
+	_, err := api.LookupAccounts("a", 1)
+	if !errors.Is(err, ErrTransportClosed) {
+		t.Errorf("expected ErrTransportClosed after Close, got %v", err)
+	}
+}
+
+func TestWebSocketTransport_RejectsSignedCall(t *testing.T) {
+	node := newWSStandIn(t, func(req *RPCRequest) (interface{}, time.Duration) {
+		t.Error("signed call must not reach the node")
+		return []string{}, 0
+	})
+
+	// Rejected by URL scheme...
+	_, err := NewAPI(node.URL()).SignedCall(testMethod, testParams, testAccount, testPrivateKey)
+	if err == nil || err.Error() != "signed calls can only be made when using HTTP transport" {
+		t.Errorf("expected transport error for ws URL, got %v", err)
+	}
+
+	// ...and when the WebSocket transport is installed explicitly.
+	api := NewAPI("http://127.0.0.1:1", WithTransport(NewWebSocketTransport(node.URL())))
+	_, err = api.SignedCall(testMethod, testParams, testAccount, testPrivateKey)
+	if err == nil || err.Error() != "signed calls can only be made when using HTTP transport" {
+		t.Errorf("expected transport error for explicit WebSocketTransport, got %v", err)
+	}
+}
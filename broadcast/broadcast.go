@@ -1,52 +1,52 @@
 package broadcast
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"os"
 	"sort"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/steemit/steemgosdk/api"
-	"github.com/steemit/steemutil/jsonrpc2"
 	"github.com/steemit/steemutil/protocol"
 	"github.com/steemit/steemutil/transaction"
 	"github.com/steemit/steemutil/wif"
 )
 
 // Broadcast provides methods to sign and broadcast transactions.
+//
+// As with api.API, every network-bound method has a variant suffixed with
+// Context whose ctx is bound to the node requests it makes (dynamic global
+// properties, reference block and the broadcast itself).
 type Broadcast struct {
 	url string
-	rpc *jsonrpc2.JsonRpc
 	api *api.API
 }
 
 // NewBroadcast creates a new Broadcast instance.
-func NewBroadcast(url string) *Broadcast {
+//
+// All node requests go through an internal api.API built from url and opts,
+// so the same options (e.g. api.WithTransport) configure how transactions are
+// broadcast.
+func NewBroadcast(url string, opts ...api.Option) *Broadcast {
 	return &Broadcast{
 		url: url,
-		rpc: jsonrpc2.NewClient(url),
-		api: api.NewAPI(url), // Create API instance internally for getting dynamic global properties
+		api: api.NewAPI(url, opts...), // Create API instance internally for RPC calls and dynamic global properties
 	}
 }
 
 // BroadcastSync broadcasts a transaction synchronously to the Steem blockchain.
 func (b *Broadcast) BroadcastSync(params []interface{}) (resultJson []byte, err error) {
-	err = b.rpc.BuildSendData(
-		"condenser_api.broadcast_transaction_synchronous",
-		params,
-	)
-	if err != nil {
-		return
-	}
-	rpcResponse, err := b.rpc.Send()
+	return b.BroadcastSyncContext(context.Background(), params)
+}
+
+// BroadcastSyncContext is like BroadcastSync but aborts the request when ctx is done.
+func (b *Broadcast) BroadcastSyncContext(ctx context.Context, params []interface{}) (resultJson []byte, err error) {
+	rpcResponse, err := b.api.CallContext(ctx, "condenser_api", "broadcast_transaction_synchronous", params)
+	b.api.Metrics().ObserveBroadcast("sync", api.ErrorClass(err))
 	if err != nil {
-		return
-	}
-	if rpcResponse.Error != nil {
-		return resultJson, errors.Errorf("failed to broadcast:%v\n", rpcResponse.Error)
+		return nil, errors.Wrap(err, "failed to broadcast")
 	}
 	resultJson, err = json.Marshal(rpcResponse.Result)
 	return
@@ -56,54 +56,33 @@ func (b *Broadcast) BroadcastSync(params []interface{}) (resultJson []byte, err
 // This returns immediately without waiting for block confirmation.
 // Use this for faster response times when you don't need immediate confirmation.
 func (b *Broadcast) BroadcastAsync(params []interface{}) error {
-	err := b.rpc.BuildSendData(
-		"condenser_api.broadcast_transaction",
-		params,
-	)
-	if err != nil {
-		return err
-	}
-	rpcResponse, err := b.rpc.Send()
+	return b.BroadcastAsyncContext(context.Background(), params)
+}
+
+// BroadcastAsyncContext is like BroadcastAsync but aborts the request when ctx is done.
+func (b *Broadcast) BroadcastAsyncContext(ctx context.Context, params []interface{}) error {
+	_, err := b.api.CallContext(ctx, "condenser_api", "broadcast_transaction", params)
+	b.api.Metrics().ObserveBroadcast("async", api.ErrorClass(err))
 	if err != nil {
-		return err
-	}
-	if rpcResponse.Error != nil {
-		return errors.Errorf("failed to broadcast:%v\n", rpcResponse.Error)
+		return errors.Wrap(err, "failed to broadcast")
 	}
 	return nil
 }
 
 // Send signs and broadcasts a transaction.
 func (b *Broadcast) Send(ops []protocol.Operation, privKeys map[string]string) ([]byte, error) {
+	return b.SendContext(context.Background(), ops, privKeys)
+}
+
+// SendContext is like Send but aborts the node requests when ctx is done.
+func (b *Broadcast) SendContext(ctx context.Context, ops []protocol.Operation, privKeys map[string]string) ([]byte, error) {
 	// Prepare transaction
-	tx, err := b.prepareTransaction(ops)
+	tx, err := b.prepareTransaction(ctx, ops)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to prepare transaction")
 	}
 
-	// Print debug information if DEBUG environment variable is set
-	if os.Getenv("DEBUG") != "" {
-		// Print transaction for testing
-		txJSON, err := json.MarshalIndent(tx.Transaction, "", "  ")
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to marshal transaction to JSON")
-		}
-		fmt.Printf("=== Transaction (before signing) ===\n%s\n", string(txJSON))
-
-		// Serialize and print transaction bytes for testing
-		txBytes, err := tx.Serialize()
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to serialize transaction")
-		}
-		fmt.Printf("=== Transaction Bytes (hex) ===\n%s\n", hex.EncodeToString(txBytes))
-
-		// Compute and print digest for testing
-		digest, err := tx.Digest(transaction.SteemChain)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to compute digest")
-		}
-		fmt.Printf("=== Digest (hex) ===\n%s\n", hex.EncodeToString(digest))
-	}
+	b.logUnsigned(ctx, tx)
 
 	// Convert WIF strings to PrivateKey objects
 	privKeyObjs := make([]*wif.PrivateKey, 0, len(privKeys))
@@ -120,68 +99,90 @@ func (b *Broadcast) Send(ops []protocol.Operation, privKeys map[string]string) (
 		return nil, errors.Wrap(err, "failed to sign transaction")
 	}
 
-	// Debug: Verify signature recovery if DEBUG is set
-	if os.Getenv("DEBUG") != "" && len(tx.Transaction.Signatures) > 0 {
+	b.logSigned(ctx, tx, privKeyObjs)
+
+	// Broadcast transaction
+	result, err := b.BroadcastSyncContext(ctx, []interface{}{tx})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to broadcast transaction")
+	}
+
+	return result, nil
+}
+
+// logUnsigned emits the transaction, its serialized bytes and its digest as
+// a debug event, if the logger wants debug events.
+func (b *Broadcast) logUnsigned(ctx context.Context, tx *transaction.SignedTransaction) {
+	logger := b.api.Logger()
+	if !logger.Enabled(ctx, api.LevelDebug) {
+		return
+	}
+	keyvals := []interface{}{"transaction", transactionJSON(tx)}
+	if txBytes, err := tx.Serialize(); err == nil {
+		keyvals = append(keyvals, "bytes", hex.EncodeToString(txBytes))
+	} else {
+		keyvals = append(keyvals, "serialize_error", err.Error())
+	}
+	if digest, err := tx.Digest(transaction.SteemChain); err == nil {
+		keyvals = append(keyvals, "digest", hex.EncodeToString(digest))
+	} else {
+		keyvals = append(keyvals, "digest_error", err.Error())
+	}
+	logger.Log(ctx, api.LevelDebug, "transaction prepared", keyvals...)
+}
+
+// logSigned emits the signed transaction as a debug event, with the public
+// key recovered from the first signature and whether it matches the first
+// signing key.
+func (b *Broadcast) logSigned(ctx context.Context, tx *transaction.SignedTransaction, privKeys []*wif.PrivateKey) {
+	logger := b.api.Logger()
+	if !logger.Enabled(ctx, api.LevelDebug) {
+		return
+	}
+	keyvals := []interface{}{
+		"transaction", transactionJSON(tx),
+		"signatures", tx.Transaction.Signatures,
+	}
+	if len(tx.Transaction.Signatures) > 0 {
 		digest, err := tx.Digest(transaction.SteemChain)
+		var sigBytes []byte
 		if err == nil {
-			// Decode first signature
-			sigHex := tx.Transaction.Signatures[0]
-			sigBytes, err := hex.DecodeString(sigHex)
-			if err == nil {
-				// Recover public key from signature
-				recoveredPubKey, err := wif.RecoverPublicKeyFromSignature(digest, sigBytes)
-				if err == nil {
-					recoveredPubKeyStr := recoveredPubKey.ToStr()
-					fmt.Printf("=== Signature Recovery ===\n")
-					fmt.Printf("Recovered Public Key from Signature: %s\n", recoveredPubKeyStr)
-
-					// Compare with expected public key from first private key
-					if len(privKeyObjs) > 0 {
-						expectedPubKeyStr := privKeyObjs[0].ToPubKeyStr()
-						fmt.Printf("Expected Public Key (from WIF): %s\n", expectedPubKeyStr)
-						if recoveredPubKeyStr == expectedPubKeyStr {
-							fmt.Printf("✅ Recovered public key matches expected public key\n")
-						} else {
-							fmt.Printf("❌ Recovered public key does NOT match expected public key\n")
-						}
-					}
-				}
-			}
+			sigBytes, err = hex.DecodeString(tx.Transaction.Signatures[0])
 		}
-	}
-
-	// Debug: Print signed transaction before broadcast
-	if os.Getenv("DEBUG") != "" {
-		txJSON, err := json.MarshalIndent(tx.Transaction, "", "  ")
+		var recovered *wif.PublicKey
 		if err == nil {
-			fmt.Printf("=== Transaction (after signing, before broadcast) ===\n%s\n", string(txJSON))
+			recovered, err = wif.RecoverPublicKeyFromSignature(digest, sigBytes)
 		}
-		// Print signatures
-		if len(tx.Transaction.Signatures) > 0 {
-			fmt.Printf("=== Signatures ===\n")
-			for i, sig := range tx.Transaction.Signatures {
-				fmt.Printf("Signature %d: %s\n", i, sig)
+		if err == nil {
+			keyvals = append(keyvals, "recovered_key", recovered.ToStr())
+			if len(privKeys) > 0 {
+				expected := privKeys[0].ToPubKeyStr()
+				keyvals = append(keyvals, "expected_key", expected, "key_match", recovered.ToStr() == expected)
 			}
+		} else {
+			keyvals = append(keyvals, "recovery_error", err.Error())
 		}
 	}
+	logger.Log(ctx, api.LevelDebug, "transaction signed", keyvals...)
+}
 
-	// Broadcast transaction
-	result, err := b.BroadcastSync([]interface{}{tx})
+// transactionJSON renders tx for log events.
+func transactionJSON(tx *transaction.SignedTransaction) json.RawMessage {
+	txJSON, err := json.Marshal(tx.Transaction)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to broadcast transaction")
+		return nil
 	}
-
-	return result, nil
+	return txJSON
 }
 
 // prepareTransaction prepares a transaction with proper ref_block_num, ref_block_prefix, and expiration.
-func (b *Broadcast) prepareTransaction(ops []protocol.Operation) (*transaction.SignedTransaction, error) {
+func (b *Broadcast) prepareTransaction(ctx context.Context, ops []protocol.Operation) (*transaction.SignedTransaction, error) {
 	if len(ops) == 0 {
 		return nil, errors.New("no operations provided")
 	}
 
 	// Get dynamic global properties
-	dgp, err := b.api.GetDynamicGlobalProperties()
+	dgp, err := b.api.GetDynamicGlobalPropertiesContext(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get dynamic global properties")
 	}
@@ -191,7 +192,7 @@ func (b *Broadcast) prepareTransaction(ops []protocol.Operation) (*transaction.S
 	refBlockNum := transaction.RefBlockNum(protocol.UInt32((dgp.LastIrreversibleBlockNum - 1) & 0xFFFF))
 
 	// Get the block at last_irreversible_block_num to get its previous block ID
-	block, err := b.api.GetBlock(uint(dgp.LastIrreversibleBlockNum))
+	block, err := b.api.GetBlockContext(ctx, uint(dgp.LastIrreversibleBlockNum))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get block for ref_block_prefix calculation")
 	}
@@ -230,17 +231,27 @@ func (b *Broadcast) prepareTransaction(ops []protocol.Operation) (*transaction.S
 
 // SendWith prepares and sends a transaction with the given operation and private key.
 func (b *Broadcast) SendWith(op protocol.Operation, privKeyWif string) ([]byte, error) {
+	return b.SendWithContext(context.Background(), op, privKeyWif)
+}
+
+// SendWithContext is like SendWith but aborts the node requests when ctx is done.
+func (b *Broadcast) SendWithContext(ctx context.Context, op protocol.Operation, privKeyWif string) ([]byte, error) {
 	privKeys := map[string]string{
 		"key": privKeyWif,
 	}
-	return b.Send([]protocol.Operation{op}, privKeys)
+	return b.SendContext(ctx, []protocol.Operation{op}, privKeys)
 }
 
 // SendAsync signs and broadcasts a transaction asynchronously.
 // Returns the transaction ID immediately without waiting for block confirmation.
 func (b *Broadcast) SendAsync(ops []protocol.Operation, privKeys map[string]string) (trxId string, err error) {
+	return b.SendAsyncContext(context.Background(), ops, privKeys)
+}
+
+// SendAsyncContext is like SendAsync but aborts the node requests when ctx is done.
+func (b *Broadcast) SendAsyncContext(ctx context.Context, ops []protocol.Operation, privKeys map[string]string) (trxId string, err error) {
 	// Prepare transaction
-	tx, err := b.prepareTransaction(ops)
+	tx, err := b.prepareTransaction(ctx, ops)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to prepare transaction")
 	}
@@ -264,7 +275,7 @@ func (b *Broadcast) SendAsync(ops []protocol.Operation, privKeys map[string]stri
 	trxId = tx.ID()
 
 	// Broadcast asynchronously
-	err = b.BroadcastAsync([]interface{}{tx})
+	err = b.BroadcastAsyncContext(ctx, []interface{}{tx})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to broadcast transaction")
 	}
@@ -274,10 +285,15 @@ func (b *Broadcast) SendAsync(ops []protocol.Operation, privKeys map[string]stri
 
 // SendWithAsync prepares and sends a transaction asynchronously with the given operation and private key.
 func (b *Broadcast) SendWithAsync(op protocol.Operation, privKeyWif string) (trxId string, err error) {
+	return b.SendWithAsyncContext(context.Background(), op, privKeyWif)
+}
+
+// SendWithAsyncContext is like SendWithAsync but aborts the node requests when ctx is done.
+func (b *Broadcast) SendWithAsyncContext(ctx context.Context, op protocol.Operation, privKeyWif string) (trxId string, err error) {
 	privKeys := map[string]string{
 		"key": privKeyWif,
 	}
-	return b.SendAsync([]protocol.Operation{op}, privKeys)
+	return b.SendAsyncContext(ctx, []protocol.Operation{op}, privKeys)
 }
 
 // CustomJson creates and broadcasts a custom_json operation.
@@ -292,6 +308,11 @@ func (b *Broadcast) SendWithAsync(op protocol.Operation, privKeyWif string) (trx
 //
 // Returns the broadcast result or an error.
 func (b *Broadcast) CustomJson(requiredAuths, requiredPostingAuths []string, id, json, privKeyWif string) ([]byte, error) {
+	return b.CustomJsonContext(context.Background(), requiredAuths, requiredPostingAuths, id, json, privKeyWif)
+}
+
+// CustomJsonContext is like CustomJson but aborts the node requests when ctx is done.
+func (b *Broadcast) CustomJsonContext(ctx context.Context, requiredAuths, requiredPostingAuths []string, id, json, privKeyWif string) ([]byte, error) {
 	// Sort required_auths and required_posting_auths to ensure consistent serialization
 	// This matches steem-js behavior where flat_set fields are sorted
 	sortedRequiredAuths := make([]string, len(requiredAuths))
@@ -321,7 +342,7 @@ func (b *Broadcast) CustomJson(requiredAuths, requiredPostingAuths []string, id,
 		keyType: privKeyWif,
 	}
 
-	return b.Send([]protocol.Operation{op}, privKeys)
+	return b.SendContext(ctx, []protocol.Operation{op}, privKeys)
 }
 
 // ExchangeRate is the exchange_rate payload for feed_publish.
@@ -334,8 +355,13 @@ type ExchangeRate struct {
 // privKeyWif must be the publisher account's active or owner private key (WIF format).
 // Witness block signing keys cannot be used for this operation.
 func (b *Broadcast) FeedPublish(publisher string, rate ExchangeRate, privKeyWif string) ([]byte, error) {
+	return b.FeedPublishContext(context.Background(), publisher, rate, privKeyWif)
+}
+
+// FeedPublishContext is like FeedPublish but aborts the node requests when ctx is done.
+func (b *Broadcast) FeedPublishContext(ctx context.Context, publisher string, rate ExchangeRate, privKeyWif string) ([]byte, error) {
 	op := buildFeedPublishOperation(publisher, rate)
-	return b.SendWith(op, privKeyWif)
+	return b.SendWithContext(ctx, op, privKeyWif)
 }
 
 func buildFeedPublishOperation(publisher string, rate ExchangeRate) *protocol.FeedPublishOperation {
@@ -0,0 +1,61 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steemit/steemgosdk/api"
+	"github.com/steemit/steemutil/protocol"
+)
+
+type event struct {
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	events []event
+}
+
+func (l *recordingLogger) Enabled(ctx context.Context, level api.LogLevel) bool {
+	return true
+}
+
+func (l *recordingLogger) Log(ctx context.Context, level api.LogLevel, msg string, keyvals ...interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fields[keyvals[i].(string)] = keyvals[i+1]
+	}
+	l.events = append(l.events, event{msg, fields})
+}
+
+func TestSend_DebugEvents(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error) {
+		result := `{}`
+		switch req.Method {
+		case "condenser_api.get_dynamic_global_properties":
+			result = `{"head_block_number":101,"last_irreversible_block_num":100}`
+		case "condenser_api.get_block":
+			result = `{"previous":"00000063b5f4a3a1c1a7b1e0f1b0a2c3d4e5f601","transactions":[]}`
+		}
+		return &api.RPCResponse{ID: req.ID, Result: json.RawMessage(result)}, nil
+	})
+	logger := &recordingLogger{}
+	b := NewBroadcast("http://unused.invalid", api.WithTransport(transport), api.WithLogger(logger))
+
+	vote := &protocol.VoteOperation{Voter: "alice", Author: "bob", Permlink: "post", Weight: 10000}
+	if _, err := b.SendWith(vote, "5JLw5dgQAx6rhZEgNN5C2ds1V47RweGshynFSWFbaMohsYsBvE8"); err != nil {
+		t.Fatalf("SendWith failed: %v", err)
+	}
+	if len(logger.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", logger.events)
+	}
+	prepared, signed := logger.events[0], logger.events[1]
+	if prepared.msg != "transaction prepared" || prepared.fields["digest"] == nil || prepared.fields["bytes"] == nil {
+		t.Errorf("unexpected prepared event %+v", prepared)
+	}
+	if signed.msg != "transaction signed" || signed.fields["key_match"] != true {
+		t.Errorf("unexpected signed event %+v", signed)
+	}
+}
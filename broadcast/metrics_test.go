@@ -0,0 +1,40 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/steemit/steemgosdk/api"
+)
+
+type broadcastCounter struct {
+	api.NopMetrics
+	results []string
+}
+
+func (m *broadcastCounter) ObserveBroadcast(mode string, class string) {
+	m.results = append(m.results, mode+":"+class)
+}
+
+func TestBroadcast_Metrics(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error) {
+		if req.Method == "condenser_api.broadcast_transaction_synchronous" {
+			return &api.RPCResponse{ID: req.ID, Error: json.RawMessage(`{"code":-32000,"message":"Duplicate transaction check failed"}`)}, nil
+		}
+		return &api.RPCResponse{ID: req.ID, Result: json.RawMessage(`{}`)}, nil
+	})
+	metrics := &broadcastCounter{}
+	b := NewBroadcast("http://unused.invalid", api.WithTransport(transport), api.WithMetrics(metrics))
+
+	if _, err := b.BroadcastSync([]interface{}{map[string]interface{}{}}); err == nil {
+		t.Fatal("expected sync broadcast to fail")
+	}
+	if err := b.BroadcastAsync([]interface{}{map[string]interface{}{}}); err != nil {
+		t.Fatalf("async broadcast failed: %v", err)
+	}
+	want := []string{"sync:duplicate_transaction", "async:"}
+	if len(metrics.results) != len(want) || metrics.results[0] != want[0] || metrics.results[1] != want[1] {
+		t.Errorf("got %v, want %v", metrics.results, want)
+	}
+}
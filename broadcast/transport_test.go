@@ -0,0 +1,81 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/api"
+)
+
+type funcTransport func(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error)
+
+func (f funcTransport) Send(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error) {
+	return f(ctx, req)
+}
+
+func TestNewBroadcast_WithTransport(t *testing.T) {
+	var methods []string
+	transport := funcTransport(func(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error) {
+		methods = append(methods, req.Method)
+		return &api.RPCResponse{
+			ID:     req.ID,
+			Result: json.RawMessage(`{"id":"abc","block_num":10,"trx_num":0,"expired":false}`),
+		}, nil
+	})
+	b := NewBroadcast("http://unused.invalid", api.WithTransport(transport))
+
+	result, err := b.BroadcastSync([]interface{}{map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("BroadcastSync failed: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(result, &decoded); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if decoded["id"] != "abc" {
+		t.Errorf("unexpected result: %s", result)
+	}
+	if len(methods) != 1 || methods[0] != "condenser_api.broadcast_transaction_synchronous" {
+		t.Errorf("unexpected methods sent: %v", methods)
+	}
+}
+
+func TestBroadcastSync_TypedRPCError(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error) {
+		return &api.RPCResponse{
+			ID:    req.ID,
+			Error: json.RawMessage(`{"code":-32000,"message":"Assert Exception:itr == trx_idx.end(): Duplicate transaction check failed"}`),
+		}, nil
+	})
+	b := NewBroadcast("http://unused.invalid", api.WithTransport(transport))
+
+	_, err := b.BroadcastSync([]interface{}{map[string]interface{}{}})
+	if !errors.Is(err, api.ErrDuplicateTransaction) {
+		t.Errorf("expected ErrDuplicateTransaction, got %v", err)
+	}
+	var rpcErr *api.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32000 {
+		t.Errorf("expected *api.RPCError with code -32000, got %v", err)
+	}
+}
+
+func TestBroadcastSync_Interceptors(t *testing.T) {
+	transport := funcTransport(func(ctx context.Context, req *api.RPCRequest) (*api.RPCResponse, error) {
+		return &api.RPCResponse{ID: req.ID, Result: json.RawMessage(`{"id":"abc"}`)}, nil
+	})
+	var methods []string
+	b := NewBroadcast("http://unused.invalid", api.WithTransport(transport), api.WithInterceptors(
+		func(ctx context.Context, req *api.RPCRequest, next api.Invoker) (*api.RPCResponse, error) {
+			methods = append(methods, req.Method)
+			return next(ctx, req)
+		}))
+
+	if _, err := b.BroadcastSync([]interface{}{map[string]interface{}{}}); err != nil {
+		t.Fatalf("BroadcastSync failed: %v", err)
+	}
+	if len(methods) != 1 || methods[0] != "condenser_api.broadcast_transaction_synchronous" {
+		t.Errorf("unexpected intercepted methods: %v", methods)
+	}
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+
 	sdkapi "github.com/steemit/steemgosdk/api"
 	"github.com/steemit/steemgosdk/auth"
 	"github.com/steemit/steemgosdk/broadcast"
@@ -16,6 +18,18 @@ type Client struct {
 	MaxRetry    int
 	AccountName string
 	Wifs        map[string]*wif.PrivateKey
+	// APIOptions are applied to every API and Broadcast instance the client
+	// creates, e.g. sdkapi.WithTransport to swap the wire transport.
+	APIOptions []sdkapi.Option
+	// Pool, when set, routes every request through a multi-node pool instead
+	// of Url alone. Its State can be read for dashboards.
+	Pool *sdkapi.Pool
+	// Logger, when set, receives the log events of every API and Broadcast
+	// instance the client creates.
+	Logger sdkapi.Logger
+	// Metrics, when set, receives the measurements of every API and
+	// Broadcast instance the client creates.
+	Metrics sdkapi.Metrics
 }
 
 func (c *Client) ImportWif(keyType string, privWif string) (err error) {
@@ -34,16 +48,30 @@ func (c *Client) ImportWif(keyType string, privWif string) (err error) {
 	return
 }
 
+// apiOptions returns the options every API and Broadcast is built with.
+// MaxRetry, Logger and Metrics are applied first so explicit options in APIOptions win.
+func (c *Client) apiOptions() []sdkapi.Option {
+	opts := []sdkapi.Option{sdkapi.WithMaxRetry(c.MaxRetry)}
+	if c.Pool != nil {
+		opts = append(opts, sdkapi.WithTransport(c.Pool))
+	}
+	if c.Logger != nil {
+		opts = append(opts, sdkapi.WithLogger(c.Logger))
+	}
+	if c.Metrics != nil {
+		opts = append(opts, sdkapi.WithMetrics(c.Metrics))
+	}
+	return append(opts, c.APIOptions...)
+}
+
 // GetAPI returns an API instance for making RPC calls.
 func (c *Client) GetAPI() *sdkapi.API {
-	apiClient := sdkapi.NewAPI(c.Url)
-	apiClient.SetMaxRetry(c.MaxRetry)
-	return apiClient
+	return sdkapi.NewAPI(c.Url, c.apiOptions()...)
 }
 
 // GetBroadcast returns a Broadcast instance for signing and broadcasting transactions.
 func (c *Client) GetBroadcast() *broadcast.Broadcast {
-	return broadcast.NewBroadcast(c.Url)
+	return broadcast.NewBroadcast(c.Url, c.apiOptions()...)
 }
 
 // GetAuth returns an Auth instance for authentication and key management.
@@ -70,6 +98,11 @@ func checkKeyType(keyType string) (r bool) {
 // SignedCall makes a signed RPC call using the client's stored credentials.
 // The keyType parameter specifies which private key to use (e.g., "active", "posting").
 func (c *Client) SignedCall(method string, params []interface{}, keyType string) (*protocolapi.RpcResultData, error) {
+	return c.SignedCallContext(context.Background(), method, params, keyType)
+}
+
+// SignedCallContext is like SignedCall but aborts the request when ctx is done.
+func (c *Client) SignedCallContext(ctx context.Context, method string, params []interface{}, keyType string) (*protocolapi.RpcResultData, error) {
 	if c.AccountName == "" {
 		return nil, errors.New("account name not set")
 	}
@@ -88,11 +121,16 @@ func (c *Client) SignedCall(method string, params []interface{}, keyType string)
 
 	// Get API instance and make signed call
 	api := c.GetAPI()
-	return api.SignedCall(method, params, c.AccountName, privateKeyWif)
+	return api.SignedCallContext(ctx, method, params, c.AccountName, privateKeyWif)
 }
 
 // SignedCallWithResult makes a signed RPC call and unmarshals the result into the provided result object.
 func (c *Client) SignedCallWithResult(method string, params []interface{}, keyType string, result interface{}) error {
+	return c.SignedCallWithResultContext(context.Background(), method, params, keyType, result)
+}
+
+// SignedCallWithResultContext is like SignedCallWithResult but aborts the request when ctx is done.
+func (c *Client) SignedCallWithResultContext(ctx context.Context, method string, params []interface{}, keyType string, result interface{}) error {
 	if c.AccountName == "" {
 		return errors.New("account name not set")
 	}
@@ -111,5 +149,5 @@ func (c *Client) SignedCallWithResult(method string, params []interface{}, keyTy
 
 	// Get API instance and make signed call
 	api := c.GetAPI()
-	return api.SignedCallWithResult(method, params, c.AccountName, privateKeyWif, result)
+	return api.SignedCallWithResultContext(ctx, method, params, c.AccountName, privateKeyWif, result)
 }
@@ -0,0 +1,270 @@
+// Package export turns an account's history into statements: one normalized
+// row per balance-changing operation, written as CSV or JSON Lines.
+//
+//	a := api.NewAPI("https://api.steemit.com")
+//	f, _ := os.Create("alice-2026-01.csv")
+//	n, err := export.Export(ctx, a, "alice", f, export.CSV, export.Options{
+//		From:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+//		To:      time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+//		MemoKey: memoWif,
+//	})
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/api"
+	"github.com/steemit/steemgosdk/auth"
+)
+
+// Format is the output format of Export.
+type Format int
+
+const (
+	// CSV writes a header line followed by one comma-separated row per
+	// operation, with one amount column per symbol in Symbols.
+	CSV Format = iota
+	// JSONLines writes one JSON object per operation and line.
+	JSONLines
+)
+
+// Symbols are the asset columns of CSV output, in order.
+var Symbols = []string{"STEEM", "SBD", "VESTS"}
+
+// DefaultTypes are the operation types exported unless Options.Types says
+// otherwise, and the only ones Rows can normalize: transfers, savings
+// deposits and withdrawals, rewards and their claims, interest, power-ups and
+// power-downs, conversions and market fills.
+var DefaultTypes = []string{
+	"transfer",
+	"transfer_to_savings", "transfer_from_savings", "fill_transfer_from_savings",
+	"transfer_to_vesting", "fill_vesting_withdraw",
+	"interest",
+	"convert", "fill_convert_request",
+	"fill_order",
+	"author_reward", "curation_reward", "comment_benefactor_reward", "producer_reward",
+	"claim_reward_balance",
+}
+
+// Options configures Export and Rows.
+type Options struct {
+	// From and To bound the exported period: operations at or after From and
+	// before To. Zero values leave that end open.
+	From, To time.Time
+	// Types lists the operation types to export, a subset of DefaultTypes.
+	// Defaults to DefaultTypes; Export and Rows fail on any other type.
+	Types []string
+	// MemoKey is the account's private memo key in WIF. When set, encrypted
+	// memos (starting with "#") are exported decrypted, without the "#";
+	// memos it cannot decrypt are exported as they are.
+	MemoKey string
+	// PageSize is passed on to api.IterateAccountHistory.
+	PageSize int
+}
+
+// Row is one statement line. Amounts are signed from the account's point of
+// view: positive amounts were received, negative ones paid.
+//
+// Amounts change the account's holdings, savings and reward balances
+// included. Operations that only move funds between the account's own
+// balances, such as claim_reward_balance, a deposit into its own savings or
+// a power-up of its own vesting shares, are exported without amounts. The
+// exception is fill_vesting_withdraw, which carries both the VESTS withdrawn
+// and the STEEM deposited.
+type Row struct {
+	Timestamp time.Time `json:"timestamp"`
+	Block     uint32    `json:"block"`
+	TrxID     string    `json:"trx_id"`
+	Index     int64     `json:"index"`
+	OpType    string    `json:"op_type"`
+	// Counterparty is the other account involved, if any.
+	Counterparty string `json:"counterparty,omitempty"`
+	// Amounts maps asset symbols to signed decimal amounts, e.g.
+	// {"STEEM": "-1.000"}.
+	Amounts map[string]string `json:"amounts"`
+	Memo    string            `json:"memo,omitempty"`
+}
+
+// Export writes the statement of account to w in format and returns the
+// number of rows written.
+func Export(ctx context.Context, a *api.API, account string, w io.Writer, format Format, opts Options) (int, error) {
+	// Checked before the CSV header is written.
+	if err := checkTypes(opts.Types); err != nil {
+		return 0, err
+	}
+	var write func(*Row) error
+	var flush func() error
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		header := append([]string{"timestamp", "block", "trx_id", "index", "op_type", "counterparty"}, Symbols...)
+		if err := cw.Write(append(header, "memo")); err != nil {
+			return 0, errors.Wrap(err, "failed to write statement")
+		}
+		write = func(r *Row) error {
+			return cw.Write(csvRecord(r))
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case JSONLines:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		write = func(r *Row) error {
+			return enc.Encode(r)
+		}
+		flush = func() error { return nil }
+	default:
+		return 0, errors.Errorf("unknown export format %d", format)
+	}
+
+	var n int
+	err := Rows(ctx, a, account, opts, func(r *Row) error {
+		if err := write(r); err != nil {
+			return errors.Wrap(err, "failed to write statement")
+		}
+		n++
+		return nil
+	})
+	if ferr := flush(); err == nil && ferr != nil {
+		err = errors.Wrap(ferr, "failed to write statement")
+	}
+	return n, err
+}
+
+// checkTypes rejects the operation types normalize does not handle.
+func checkTypes(types []string) error {
+	for _, t := range types {
+		if !isDefaultType(t) {
+			return errors.Errorf("operation type %s cannot be exported", t)
+		}
+	}
+	return nil
+}
+
+func isDefaultType(opType string) bool {
+	for _, t := range DefaultTypes {
+		if t == opType {
+			return true
+		}
+	}
+	return false
+}
+
+func csvRecord(r *Row) []string {
+	record := []string{
+		r.Timestamp.UTC().Format(time.RFC3339),
+		strconv.FormatUint(uint64(r.Block), 10),
+		r.TrxID,
+		strconv.FormatInt(r.Index, 10),
+		r.OpType,
+		r.Counterparty,
+	}
+	for _, symbol := range Symbols {
+		record = append(record, r.Amounts[symbol])
+	}
+	return append(record, r.Memo)
+}
+
+// Rows walks the history of account and hands every row of the period in
+// opts to fn, oldest first. Returning an error from fn stops the walk.
+//
+// When opts.From is set, Rows first walks back from the newest entry to find
+// where the period starts, so the forward walk does not have to begin at the
+// account's first operation.
+func Rows(ctx context.Context, a *api.API, account string, opts Options, fn func(*Row) error) error {
+	types := opts.Types
+	if len(types) == 0 {
+		types = DefaultTypes
+	}
+	if err := checkTypes(types); err != nil {
+		return err
+	}
+
+	var start int64
+	if !opts.From.IsZero() {
+		it := a.IterateAccountHistory(ctx, account, api.AccountHistoryOptions{
+			Types:    types,
+			PageSize: opts.PageSize,
+			StopTime: opts.From,
+		})
+		start = -1
+		for it.Next() {
+			start = it.Entry().Index
+		}
+		if err := it.Err(); err != nil {
+			return errors.Wrapf(err, "failed to export history of %s", account)
+		}
+		if start < 0 {
+			return nil // nothing since From
+		}
+	}
+
+	memos := &memoDecoder{key: opts.MemoKey, auth: auth.NewAuth()}
+	it := a.IterateAccountHistory(ctx, account, api.AccountHistoryOptions{
+		Direction: api.HistoryForward,
+		Start:     &start,
+		Types:     types,
+		PageSize:  opts.PageSize,
+	})
+	for it.Next() {
+		e := it.Entry()
+		ts, err := parseTimestamp(e.Timestamp)
+		if err != nil {
+			return errors.Wrapf(err, "invalid timestamp of history entry %d", e.Index)
+		}
+		if ts.Before(opts.From) {
+			continue
+		}
+		if !opts.To.IsZero() && !ts.Before(opts.To) {
+			return nil
+		}
+		row := normalize(account, e)
+		if row == nil {
+			continue
+		}
+		row.Timestamp = ts
+		row.Memo = memos.decode(row.Memo)
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return errors.Wrapf(err, "failed to export history of %s", account)
+	}
+	return nil
+}
+
+// memoDecoder decrypts "#" memos with the account's memo key.
+type memoDecoder struct {
+	key  string
+	auth *auth.Auth
+}
+
+func (d *memoDecoder) decode(memo string) string {
+	if d.key == "" || len(memo) == 0 || memo[0] != '#' {
+		return memo
+	}
+	plain, err := d.auth.DecodeMemo(d.key, memo)
+	if err != nil {
+		return memo
+	}
+	// The decrypted text keeps the "#" the sender typed to ask for
+	// encryption.
+	return strings.TrimPrefix(plain, "#")
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if ts, err := time.Parse("2006-01-02T15:04:05", s); err == nil {
+		return ts, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,197 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steemit/steemgosdk/api"
+	"github.com/steemit/steemgosdk/auth"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// historyNode returns a node where alice's history holds ops one day apart
+// from 2026-01-01, op i in block 100+i of transaction "trx" + 'a'+i.
+func historyNode(t *testing.T, ops ...steemtest.Op) *steemtest.Node {
+	t.Helper()
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, op := range ops {
+		node.AddHistoryEntry("alice", steemtest.HistoryEntry{
+			Op:        op,
+			Block:     uint32(100 + i),
+			Timestamp: base.AddDate(0, 0, i),
+			TrxID:     "trx" + string(rune('a'+i)),
+		})
+	}
+	return node
+}
+
+func op(kind string, payload map[string]interface{}) steemtest.Op {
+	return steemtest.Op{Type: kind, Value: payload}
+}
+
+func TestExport_CSV(t *testing.T) {
+	node := historyNode(t,
+		op("transfer", map[string]interface{}{"from": "bob", "to": "alice", "amount": "10.000 STEEM", "memo": "rent"}),
+		op("vote", map[string]interface{}{"voter": "alice", "author": "bob", "permlink": "p", "weight": 10000}),
+		op("transfer", map[string]interface{}{"from": "alice", "to": "carol", "amount": "2.500 SBD", "memo": ""}),
+		op("author_reward", map[string]interface{}{"author": "alice", "permlink": "p", "sbd_payout": "1.000 SBD", "steem_payout": "0.000 STEEM", "vesting_payout": "2000.000000 VESTS"}),
+		op("fill_order", map[string]interface{}{"current_owner": "alice", "current_orderid": 1, "current_pays": "5.000 STEEM", "open_owner": "dave", "open_orderid": 2, "open_pays": "1.250 SBD"}),
+	)
+	var out bytes.Buffer
+	n, err := Export(context.Background(), api.NewAPI(node.URL), "alice", &out, CSV, Options{})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("expected 4 rows (the vote is skipped), got %d", n)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"timestamp", "block", "trx_id", "index", "op_type", "counterparty", "STEEM", "SBD", "VESTS", "memo"},
+		{"2026-01-01T00:00:00Z", "100", "trxa", "0", "transfer", "bob", "10.000", "", "", "rent"},
+		{"2026-01-03T00:00:00Z", "102", "trxc", "2", "transfer", "carol", "", "-2.500", "", ""},
+		{"2026-01-04T00:00:00Z", "103", "trxd", "3", "author_reward", "", "", "1.000", "2000.000000", ""},
+		{"2026-01-05T00:00:00Z", "104", "trxe", "4", "fill_order", "dave", "-5.000", "1.250", "", ""},
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d:\n got %v\nwant %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestExport_JSONLinesDateRangeAndMemo(t *testing.T) {
+	a := auth.NewAuth()
+	alice, _ := a.GetPrivateKeys("alice", "alice-password", []string{"memo"})
+	bob, _ := a.GetPrivateKeys("bob", "bob-password", []string{"memo"})
+	secret, err := a.EncodeMemo(bob["memo"], alice["memoPubkey"], "#invoice 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := historyNode(t,
+		op("transfer", map[string]interface{}{"from": "bob", "to": "alice", "amount": "1.000 STEEM", "memo": "too early"}),
+		op("transfer", map[string]interface{}{"from": "bob", "to": "alice", "amount": "2.000 STEEM", "memo": secret}),
+		op("interest", map[string]interface{}{"owner": "alice", "interest": "0.010 SBD"}),
+		op("transfer", map[string]interface{}{"from": "bob", "to": "alice", "amount": "3.000 STEEM", "memo": "too late"}),
+	)
+	var out bytes.Buffer
+	n, err := Export(context.Background(), api.NewAPI(node.URL), "alice", &out, JSONLines, Options{
+		From:     time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+		MemoKey:  alice["memo"],
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected the 2 rows inside the range, got %d:\n%s", n, out.String())
+	}
+	var rows []Row
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r Row
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		rows = append(rows, r)
+	}
+	if rows[0].Index != 1 || rows[0].Memo != "invoice 42" {
+		t.Errorf("expected the decrypted memo, got %+v", rows[0])
+	}
+	if rows[1].OpType != "interest" || rows[1].Amounts["SBD"] != "0.010" {
+		t.Errorf("unexpected interest row %+v", rows[1])
+	}
+}
+
+// TestExport_SavingsVestingAndClaims checks the savings and claim operations
+// of DefaultTypes, power-ups received from another account and made to the
+// account's own vesting shares, and that the node-side type filter keeps
+// other operations out of the export.
+func TestExport_SavingsVestingAndClaims(t *testing.T) {
+	node := historyNode(t,
+		op("transfer_to_savings", map[string]interface{}{"from": "alice", "to": "alice", "amount": "5.000 STEEM", "memo": ""}),
+		op("transfer_to_savings", map[string]interface{}{"from": "bob", "to": "alice", "amount": "1.000 SBD", "memo": "gift"}),
+		op("transfer_from_savings", map[string]interface{}{"from": "alice", "request_id": 7, "to": "carol", "amount": "2.000 STEEM", "memo": "rent"}),
+		op("vote", map[string]interface{}{"voter": "alice", "author": "bob", "permlink": "p", "weight": 10000}),
+		op("fill_transfer_from_savings", map[string]interface{}{"from": "alice", "to": "carol", "amount": "2.000 STEEM", "request_id": 7, "memo": "rent"}),
+		op("transfer_to_vesting", map[string]interface{}{"from": "bob", "to": "alice", "amount": "3.000 STEEM"}),
+		op("transfer_to_vesting", map[string]interface{}{"from": "alice", "to": "", "amount": "4.000 STEEM"}),
+		op("claim_reward_balance", map[string]interface{}{"account": "alice", "reward_steem": "0.000 STEEM", "reward_sbd": "1.000 SBD", "reward_vests": "10.000000 VESTS"}),
+	)
+	var out bytes.Buffer
+	n, err := Export(context.Background(), api.NewAPI(node.URL), "alice", &out, CSV, Options{PageSize: 2})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if n != 7 {
+		t.Fatalf("expected 7 rows (the vote is filtered out), got %d:\n%s", n, out.String())
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"2026-01-01T00:00:00Z", "100", "trxa", "0", "transfer_to_savings", "", "", "", "", ""},
+		{"2026-01-02T00:00:00Z", "101", "trxb", "1", "transfer_to_savings", "bob", "", "1.000", "", "gift"},
+		{"2026-01-03T00:00:00Z", "102", "trxc", "2", "transfer_from_savings", "carol", "", "", "", "rent"},
+		{"2026-01-05T00:00:00Z", "104", "trxe", "4", "fill_transfer_from_savings", "carol", "-2.000", "", "", "rent"},
+		{"2026-01-06T00:00:00Z", "105", "trxf", "5", "transfer_to_vesting", "bob", "3.000", "", "", ""},
+		{"2026-01-07T00:00:00Z", "106", "trxg", "6", "transfer_to_vesting", "", "", "", "", ""},
+		{"2026-01-08T00:00:00Z", "107", "trxh", "7", "claim_reward_balance", "", "", "", "", ""},
+	}
+	for i := range want {
+		if strings.Join(records[i+1], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d:\n got %v\nwant %v", i, records[i+1], want[i])
+		}
+	}
+}
+
+func TestExport_TypesFilter(t *testing.T) {
+	node := historyNode(t,
+		op("interest", map[string]interface{}{"owner": "alice", "interest": "0.010 SBD"}),
+		op("transfer", map[string]interface{}{"from": "bob", "to": "alice", "amount": "1.000 STEEM", "memo": ""}),
+		op("interest", map[string]interface{}{"owner": "alice", "interest": "0.020 SBD"}),
+	)
+	var out bytes.Buffer
+	n, err := Export(context.Background(), api.NewAPI(node.URL), "alice", &out, JSONLines, Options{Types: []string{"transfer"}})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if n != 1 || !strings.Contains(out.String(), `"op_type":"transfer"`) {
+		t.Errorf("expected only the transfer, got %d rows:\n%s", n, out.String())
+	}
+
+	_, err = Export(context.Background(), api.NewAPI(node.URL), "alice", &out, JSONLines, Options{Types: []string{"transfer", "vote"}})
+	if err == nil || !strings.Contains(err.Error(), "vote cannot be exported") {
+		t.Errorf("expected an unsupported type to be rejected, got %v", err)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	cases := []struct {
+		amount    int64
+		precision uint8
+		want      string
+	}{
+		{1, 3, "0.001"},
+		{-1500, 3, "-1.500"},
+		{123456, 6, "0.123456"},
+		{42, 0, "42"},
+	}
+	for _, tc := range cases {
+		if got := formatAmount(tc.amount, tc.precision); got != tc.want {
+			t.Errorf("formatAmount(%d, %d) = %s, want %s", tc.amount, tc.precision, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/steemit/steemgosdk/api"
+	"github.com/steemit/steemutil/protocol"
+)
+
+// normalize maps a history entry of account to a statement row, or returns
+// nil if the entry is not about the account's funds.
+func normalize(account string, e *api.AccountHistoryEntry) *Row {
+	r := &Row{
+		Block:  e.BlockNumber,
+		TrxID:  e.TransactionID,
+		Index:  e.Index,
+		OpType: e.Op.Type,
+	}
+	l := ledger{}
+	switch op := e.Op.Data.(type) {
+	case *protocol.TransferOperation:
+		r.Memo = op.Memo
+		bookTransfer(r, l, account, op.From, op.To, op.Amount)
+	case *protocol.TransferToSavingsOperation:
+		r.Memo = op.Memo
+		bookTransfer(r, l, account, op.From, op.To, op.Amount)
+	case *protocol.TransferFromSavingsOperation:
+		// The funds leave savings when the withdrawal is filled, so that a
+		// cancelled request books nothing; the request carries the memo.
+		r.Memo = op.Memo
+		if op.From != account {
+			r.Counterparty = op.From
+		} else if op.To != account {
+			r.Counterparty = op.To
+		}
+	case *protocol.FillTransferFromSavingsOperation:
+		r.Memo = op.Memo
+		bookTransfer(r, l, account, op.From, op.To, op.Amount)
+	case *protocol.TransferToVestingOperation:
+		// The vesting shares bought are not part of the operation, so a
+		// power-up of the account's own vesting shares books nothing. A
+		// recipient other than the payer is credited with the STEEM powered
+		// up for it.
+		to := op.To
+		if to == "" {
+			to = op.From
+		}
+		switch {
+		case op.From == account && to == account:
+		case op.From == account:
+			r.Counterparty = to
+			l.add(op.Amount, -1)
+		case to == account:
+			r.Counterparty = op.From
+			l.add(op.Amount, 1)
+		}
+	case *protocol.FillVestingWithdrawOperation:
+		if op.FromAccount == account {
+			l.add(op.Withdrawn, -1)
+		}
+		if op.ToAccount == account {
+			l.add(op.Deposited, 1)
+		}
+		if op.FromAccount != account {
+			r.Counterparty = op.FromAccount
+		} else if op.ToAccount != account {
+			r.Counterparty = op.ToAccount
+		}
+	case *protocol.InterestOperation:
+		l.add(op.Interest, 1)
+	case *protocol.ConvertOperation:
+		l.add(op.Amount, -1)
+	case *protocol.FillConvertRequestOperation:
+		// The SBD left the balance with the convert operation.
+		l.add(op.AmountOut, 1)
+	case *protocol.FillOrderOperation:
+		if op.CurrentOwner == account {
+			r.Counterparty = op.OpenOwner
+			l.add(op.CurrentPays, -1)
+			l.add(op.OpenPays, 1)
+		} else {
+			r.Counterparty = op.CurrentOwner
+			l.add(op.OpenPays, -1)
+			l.add(op.CurrentPays, 1)
+		}
+	case *api.AuthorRewardOperation:
+		l.add(op.SBDPayout, 1)
+		l.add(op.SteemPayout, 1)
+		l.add(op.VestingPayout, 1)
+	case *api.CurationRewardOperation:
+		r.Counterparty = op.CommentAuthor
+		l.add(op.Reward, 1)
+	case *api.CommentBenefactorRewardOperation:
+		if op.Benefactor != account {
+			return nil
+		}
+		r.Counterparty = op.Author
+		l.add(op.SBDPayout, 1)
+		l.add(op.SteemPayout, 1)
+		l.add(op.VestingPayout, 1)
+	case *api.ProducerRewardOperation:
+		l.add(op.VestingShares, 1)
+	case *protocol.ClaimRewardBalanceOperation:
+		// The rewards were booked when paid out; claiming moves them from
+		// the reward balances into the liquid and vesting ones.
+	default:
+		return nil
+	}
+	r.Amounts = l.amounts()
+	return r
+}
+
+// bookTransfer books amount moving from one account to another, as seen by
+// account, and sets the counterparty. A move between two balances of account
+// itself nets to zero.
+func bookTransfer(r *Row, l ledger, account, from, to, amount string) {
+	if from == account {
+		l.add(amount, -1)
+		if to != account {
+			r.Counterparty = to
+		}
+	}
+	if to == account {
+		if from != account {
+			r.Counterparty = from
+		}
+		l.add(amount, 1)
+	}
+}
+
+// ledger sums the amounts of one operation by symbol.
+type ledger map[string]*protocol.Asset
+
+// add books amount, an asset string like "1.000 STEEM", with sign.
+// Unparsable amounts are left out.
+func (l ledger) add(amount string, sign int64) {
+	asset, err := protocol.ParseAsset(amount)
+	if err != nil {
+		return
+	}
+	if booked, ok := l[asset.Symbol]; ok {
+		booked.Amount += sign * asset.Amount
+		return
+	}
+	asset.Amount *= sign
+	l[asset.Symbol] = asset
+}
+
+// amounts renders the booked amounts, leaving out those that net to zero.
+func (l ledger) amounts() map[string]string {
+	amounts := make(map[string]string, len(l))
+	for symbol, asset := range l {
+		if asset.Amount != 0 {
+			amounts[symbol] = formatAmount(asset.Amount, asset.Precision)
+		}
+	}
+	return amounts
+}
+
+// formatAmount renders an integer amount with precision decimals.
+func formatAmount(amount int64, precision uint8) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= int(precision) {
+		digits = strings.Repeat("0", int(precision)-len(digits)+1) + digits
+	}
+	if precision == 0 {
+		return sign + digits
+	}
+	point := len(digits) - int(precision)
+	return sign + digits[:point] + "." + digits[point:]
+}
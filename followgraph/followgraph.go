@@ -0,0 +1,268 @@
+// Package followgraph crawls the Steem follow graph outward from a set of
+// seed accounts and exports its edges as CSV or JSON Lines.
+//
+//	a := api.NewAPI("https://api.steemit.com")
+//	n, err := followgraph.Export(ctx, a, []string{"alice", "bob"}, os.Stdout, followgraph.CSV, followgraph.Options{
+//		Depth:       2,
+//		Concurrency: 8,
+//	})
+package followgraph
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/api"
+)
+
+// Direction selects which edges of an account the crawler follows.
+type Direction int
+
+const (
+	// Following crawls the accounts each account follows.
+	Following Direction = iota
+	// Followers crawls the accounts following each account.
+	Followers
+	// Both crawls followings and followers.
+	Both
+)
+
+// Format is the output format of Export.
+type Format int
+
+const (
+	// CSV writes a follower,following,type header and one edge per line.
+	CSV Format = iota
+	// JSONLines writes one JSON object per edge and line.
+	JSONLines
+)
+
+// Options configures Crawl and Export.
+type Options struct {
+	// Depth is the number of hops from the seeds to crawl. 1 (the default)
+	// fetches the edges of the seeds only; 2 also those of the accounts they
+	// lead to, and so on.
+	Depth int
+	// Concurrency caps how many accounts are crawled at once. Defaults to 8.
+	Concurrency int
+	// Direction defaults to Following.
+	Direction Direction
+	// FollowType is "blog" (the default) or "ignore".
+	FollowType string
+	// PageSize is passed on to api.IterateFollowers and IterateFollowing.
+	PageSize int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Depth <= 0 {
+		o.Depth = 1
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 8
+	}
+	if o.FollowType == "" {
+		o.FollowType = "blog"
+	}
+	return o
+}
+
+// Edge is a follow relation: Follower follows (or, with type "ignore",
+// mutes) Following.
+type Edge struct {
+	Follower  string `json:"follower"`
+	Following string `json:"following"`
+	Type      string `json:"type"`
+}
+
+// found is an edge discovered while crawling account, or the error that
+// ended its crawl.
+type found struct {
+	account string
+	edge    Edge
+	err     error
+}
+
+// Crawl walks the follow graph breadth first from seeds and hands every edge
+// to fn once. fn is called from a single goroutine, so it needs no locking.
+// The first error, from a node request or from fn, stops the crawl.
+func Crawl(ctx context.Context, a *api.API, seeds []string, opts Options, fn func(Edge) error) error {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	seen := make(map[string]bool)
+	var level []string
+	for _, s := range seeds {
+		if !seen[s] {
+			seen[s] = true
+			level = append(level, s)
+		}
+	}
+	// Edges are only reported twice when both ends are crawled in Both mode.
+	var emitted map[Edge]bool
+	if opts.Direction == Both {
+		emitted = make(map[Edge]bool)
+	}
+
+	for depth := 0; depth < opts.Depth && len(level) > 0; depth++ {
+		var next []string
+		err := crawlLevel(ctx, a, level, opts, func(f found) error {
+			if emitted != nil {
+				if emitted[f.edge] {
+					return nil
+				}
+				emitted[f.edge] = true
+			}
+			if err := fn(f.edge); err != nil {
+				return err
+			}
+			other := f.edge.Following
+			if other == f.account {
+				other = f.edge.Follower
+			}
+			if !seen[other] {
+				seen[other] = true
+				next = append(next, other)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		level = next
+	}
+	return nil
+}
+
+// crawlLevel fetches the edges of accounts with opts.Concurrency workers and
+// hands them to handle on the calling goroutine.
+func crawlLevel(ctx context.Context, a *api.API, accounts []string, opts Options, handle func(found) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan string)
+	results := make(chan found)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for account := range jobs {
+				if !crawlAccount(ctx, a, account, opts, results) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, account := range accounts {
+			select {
+			case jobs <- account:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	for f := range results {
+		if err != nil {
+			continue // drain until the workers have stopped
+		}
+		if f.err != nil {
+			err = errors.Wrapf(f.err, "failed to crawl %s", f.account)
+		} else {
+			err = handle(f)
+		}
+		if err != nil {
+			cancel()
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// crawlAccount sends the edges of account to results. It returns false once
+// ctx is done.
+func crawlAccount(ctx context.Context, a *api.API, account string, opts Options, results chan<- found) bool {
+	var iters []*api.FollowIterator
+	if opts.Direction == Following || opts.Direction == Both {
+		iters = append(iters, a.IterateFollowing(ctx, account, opts.FollowType, opts.PageSize))
+	}
+	if opts.Direction == Followers || opts.Direction == Both {
+		iters = append(iters, a.IterateFollowers(ctx, account, opts.FollowType, opts.PageSize))
+	}
+	send := func(f found) bool {
+		select {
+		case results <- f:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for _, it := range iters {
+		for it.Next() {
+			e := it.Entry()
+			edge := Edge{Follower: e.Follower, Following: e.Following, Type: opts.FollowType}
+			if !send(found{account: account, edge: edge}) {
+				return false
+			}
+		}
+		if err := it.Err(); err != nil {
+			return send(found{account: account, err: err})
+		}
+	}
+	return true
+}
+
+// Export crawls like Crawl and writes every edge to w in format. It returns
+// the number of edges written.
+func Export(ctx context.Context, a *api.API, seeds []string, w io.Writer, format Format, opts Options) (int, error) {
+	var write func(Edge) error
+	flush := func() error { return nil }
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"follower", "following", "type"}); err != nil {
+			return 0, errors.Wrap(err, "failed to write edges")
+		}
+		write = func(e Edge) error {
+			return cw.Write([]string{e.Follower, e.Following, e.Type})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case JSONLines:
+		enc := json.NewEncoder(w)
+		write = func(e Edge) error {
+			return enc.Encode(e)
+		}
+	default:
+		return 0, errors.Errorf("unknown export format %d", format)
+	}
+
+	var n int
+	err := Crawl(ctx, a, seeds, opts, func(e Edge) error {
+		if err := write(e); err != nil {
+			return errors.Wrap(err, "failed to write edges")
+		}
+		n++
+		return nil
+	})
+	if ferr := flush(); err == nil && ferr != nil {
+		err = errors.Wrap(ferr, "failed to write edges")
+	}
+	return n, err
+}
@@ -0,0 +1,106 @@
+package followgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/api"
+	"github.com/steemit/steemgosdk/steemtest"
+)
+
+// graphNode returns a node holding follows, a map of follower to the
+// accounts it follows.
+func graphNode(t *testing.T, follows map[string][]string) *steemtest.Node {
+	node := steemtest.NewNode()
+	t.Cleanup(node.Close)
+	for follower, following := range follows {
+		for _, name := range following {
+			node.Follow(follower, name)
+		}
+	}
+	return node
+}
+
+var testGraph = map[string][]string{
+	"alice": {"bob", "carol"},
+	"bob":   {"carol", "dave"},
+	"carol": {"alice"},
+	"dave":  {"erin"},
+}
+
+func crawl(t *testing.T, seeds []string, opts Options) []string {
+	t.Helper()
+	node := graphNode(t, testGraph)
+	var edges []string
+	err := Crawl(context.Background(), api.NewAPI(node.URL), seeds, opts, func(e Edge) error {
+		edges = append(edges, e.Follower+">"+e.Following)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawl failed: %v", err)
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+func TestCrawl_Depth(t *testing.T) {
+	got := crawl(t, []string{"alice"}, Options{Depth: 1})
+	if want := "alice>bob alice>carol"; strings.Join(got, " ") != want {
+		t.Errorf("depth 1: expected %s, got %v", want, got)
+	}
+	got = crawl(t, []string{"alice"}, Options{Depth: 2, Concurrency: 2, PageSize: 2})
+	if want := "alice>bob alice>carol bob>carol bob>dave carol>alice"; strings.Join(got, " ") != want {
+		t.Errorf("depth 2: expected %s, got %v", want, got)
+	}
+}
+
+func TestCrawl_BothDeduplicatesEdges(t *testing.T) {
+	got := crawl(t, []string{"alice", "bob"}, Options{Direction: Both})
+	// alice>bob is seen from both ends but reported once.
+	if want := "alice>bob alice>carol bob>carol bob>dave carol>alice"; strings.Join(got, " ") != want {
+		t.Errorf("expected %s, got %v", want, got)
+	}
+}
+
+func TestCrawl_CallbackError(t *testing.T) {
+	node := graphNode(t, testGraph)
+	stop := errors.New("stop")
+	err := Crawl(context.Background(), api.NewAPI(node.URL), []string{"alice"}, Options{Depth: 3}, func(Edge) error {
+		return stop
+	})
+	if errors.Cause(err) != stop {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+}
+
+func TestExport_Formats(t *testing.T) {
+	node := graphNode(t, testGraph)
+	a := api.NewAPI(node.URL)
+
+	var out bytes.Buffer
+	n, err := Export(context.Background(), a, []string{"dave"}, &out, CSV, Options{})
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 edge, got %d (%v)", n, err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || strings.Join(records[0], ",") != "follower,following,type" ||
+		strings.Join(records[1], ",") != "dave,erin,blog" {
+		t.Errorf("unexpected CSV %v", records)
+	}
+
+	out.Reset()
+	if _, err := Export(context.Background(), a, []string{"dave"}, &out, JSONLines, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != `{"follower":"dave","following":"erin","type":"blog"}` {
+		t.Errorf("unexpected JSON Lines %s", got)
+	}
+}
@@ -16,6 +16,20 @@ func GetClient(url string) *client.Client {
 	}
 }
 
+// GetClientWithNodes creates a new Client whose requests are spread over
+// several nodes with failover (see api.Pool). Url is set to the first node.
+func GetClientWithNodes(urls []string, opts ...api.PoolOption) (*client.Client, error) {
+	pool, err := api.NewPool(urls, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &client.Client{
+		Url:      urls[0],
+		MaxRetry: 5,
+		Pool:     pool,
+	}, nil
+}
+
 // Client represents the main Steem SDK client.
 type Client = client.Client
 
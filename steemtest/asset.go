@@ -0,0 +1,45 @@
+package steemtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// asset is a STEEM or SBD amount in its legacy string form, e.g. "1.000 SBD".
+type asset struct {
+	amount int64 // in thousandths
+	symbol string
+}
+
+func parseAsset(s string) (asset, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 || (fields[1] != "STEEM" && fields[1] != "SBD") {
+		return asset{}, fmt.Errorf("invalid asset %q", s)
+	}
+	whole, frac, ok := strings.Cut(fields[0], ".")
+	if !ok || len(frac) != 3 {
+		return asset{}, fmt.Errorf("invalid asset %q", s)
+	}
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || amount < 0 {
+		return asset{}, fmt.Errorf("invalid asset %q", s)
+	}
+	return asset{amount: amount, symbol: fields[1]}, nil
+}
+
+func mustAsset(s string) asset {
+	a, err := parseAsset(s)
+	if err != nil {
+		panic("steemtest: " + err.Error())
+	}
+	return a
+}
+
+func (a asset) String() string {
+	return fmt.Sprintf("%d.%03d %s", a.amount/1000, a.amount%1000, a.symbol)
+}
+
+func (p price) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"base":%q,"quote":%q}`, p.base, p.quote)), nil
+}
@@ -0,0 +1,520 @@
+package steemtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steemit/steemgosdk/consts"
+	"github.com/steemit/steemutil/transaction"
+)
+
+// maxPage is the largest limit steemd accepts for paged queries.
+const maxPage = 1000
+
+const zeroID = "0000000000000000000000000000000000000000"
+
+type handler func(n *Node, params json.RawMessage) (interface{}, *rpcError)
+
+// methods are the calls a Node answers, by dotted name. The caller holds
+// n.mu.
+var methods = map[string]handler{
+	"condenser_api.get_dynamic_global_properties":     condenserGetDynamicGlobalProperties,
+	"condenser_api.get_config":                        condenserGetConfig,
+	"condenser_api.get_block":                         getBlock,
+	"condenser_api.get_ops_in_block":                  getOpsInBlock,
+	"condenser_api.get_accounts":                      getAccounts,
+	"condenser_api.lookup_accounts":                   lookupAccounts,
+	"condenser_api.get_follow_count":                  getFollowCount,
+	"condenser_api.get_followers":                     getFollowers,
+	"condenser_api.get_following":                     getFollowing,
+	"condenser_api.get_account_history":               getAccountHistory,
+	"condenser_api.get_order_book":                    condenserGetOrderBook,
+	"condenser_api.get_feed_history":                  condenserGetFeedHistory,
+	"condenser_api.broadcast_transaction":             broadcastTransaction,
+	"condenser_api.broadcast_transaction_synchronous": broadcastTransactionSynchronous,
+	"database_api.get_dynamic_global_properties":      databaseGetDynamicGlobalProperties,
+	"database_api.get_config":                         databaseGetConfig,
+	"database_api.find_accounts":                      findAccounts,
+	"database_api.list_accounts":                      listAccounts,
+	"database_api.get_order_book":                     databaseGetOrderBook,
+	"database_api.get_feed_history":                   databaseGetFeedHistory,
+}
+
+func condenserGetDynamicGlobalProperties(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	if err := positional(params, 0); err != nil {
+		return nil, err
+	}
+	return n.dynamicGlobalProperties(), nil
+}
+
+func databaseGetDynamicGlobalProperties(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	return n.dynamicGlobalProperties(), nil
+}
+
+func (n *Node) dynamicGlobalProperties() map[string]interface{} {
+	head := n.head()
+	return map[string]interface{}{
+		"head_block_number":           head.Num,
+		"head_block_id":               head.ID,
+		"time":                        head.Timestamp.Format(timeLayout),
+		"current_witness":             head.Witness,
+		"last_irreversible_block_num": n.lastIrreversible(),
+		"current_supply":              "1000000.000 STEEM",
+		"virtual_supply":              "1000000.000 STEEM",
+		"current_sbd_supply":          "0.000 SBD",
+		"total_vesting_fund_steem":    "1000000.000 STEEM",
+		"total_vesting_shares":        "2000000000.000000 VESTS",
+		"maximum_block_size":          65536,
+	}
+}
+
+func condenserGetConfig(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	if err := positional(params, 0); err != nil {
+		return nil, err
+	}
+	return config(), nil
+}
+
+func databaseGetConfig(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	return config(), nil
+}
+
+func config() map[string]interface{} {
+	return map[string]interface{}{
+		"STEEM_CHAIN_ID":       transaction.SteemChain.ID,
+		"STEEM_ADDRESS_PREFIX": "STM",
+		"STEEM_BLOCK_INTERVAL": int(BlockInterval / time.Second),
+		"STEEM_MAX_BLOCK_SIZE": 65536,
+	}
+}
+
+func getBlock(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var num uint32
+	if err := positional(params, 1, &num); err != nil {
+		return nil, err
+	}
+	b := n.block(num)
+	if b == nil {
+		return nil, nil
+	}
+	txs := make([]interface{}, len(b.Transactions))
+	ids := make([]string, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		fields := make(map[string]interface{}, len(tx.fields)+3)
+		for key, value := range tx.fields {
+			fields[key] = value
+		}
+		fields["transaction_id"] = tx.ID
+		fields["block_num"] = b.Num
+		fields["transaction_num"] = i
+		txs[i] = fields
+		ids[i] = tx.ID
+	}
+	return map[string]interface{}{
+		"block_id":                b.ID,
+		"previous":                b.Previous,
+		"timestamp":               b.Timestamp.Format(timeLayout),
+		"witness":                 b.Witness,
+		"transaction_merkle_root": zeroID,
+		"extensions":              []interface{}{},
+		"witness_signature":       strings.Repeat("0", 130),
+		"signing_key":             "",
+		"transactions":            txs,
+		"transaction_ids":         ids,
+	}, nil
+}
+
+func getOpsInBlock(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var num uint32
+	var onlyVirtual bool
+	if err := positional(params, 1, &num, &onlyVirtual); err != nil {
+		return nil, err
+	}
+	ops := []interface{}{}
+	b := n.block(num)
+	if b == nil {
+		return ops, nil
+	}
+	if !onlyVirtual {
+		for i, tx := range b.Transactions {
+			for j, op := range tx.Operations {
+				ops = append(ops, map[string]interface{}{
+					"trx_id":       tx.ID,
+					"block":        b.Num,
+					"trx_in_block": i,
+					"op_in_trx":    j,
+					"virtual_op":   0,
+					"timestamp":    b.Timestamp.Format(timeLayout),
+					"op":           op,
+				})
+			}
+		}
+	}
+	// Virtual operations of the block itself carry trx_in_block -1 as a
+	// uint32, like steemd's.
+	for i, op := range b.VirtualOps {
+		ops = append(ops, map[string]interface{}{
+			"trx_id":       zeroID,
+			"block":        b.Num,
+			"trx_in_block": uint32(0xFFFFFFFF),
+			"op_in_trx":    0,
+			"virtual_op":   i + 1,
+			"timestamp":    b.Timestamp.Format(timeLayout),
+			"op":           op,
+		})
+	}
+	return ops, nil
+}
+
+func getAccounts(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var names []string
+	if err := positional(params, 1, &names); err != nil {
+		return nil, err
+	}
+	return n.findAccounts(names), nil
+}
+
+func findAccounts(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var args struct {
+		Accounts []string `json:"accounts"`
+	}
+	if err := named(params, &args); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"accounts": n.findAccounts(args.Accounts)}, nil
+}
+
+// findAccounts returns the registered accounts among names, in their order.
+func (n *Node) findAccounts(names []string) []interface{} {
+	accounts := []interface{}{}
+	for _, name := range names {
+		if account, ok := n.accounts[name]; ok {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+func lookupAccounts(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var lowerBound string
+	var limit int
+	if err := positional(params, 2, &lowerBound, &limit); err != nil {
+		return nil, err
+	}
+	if limit > maxPage {
+		return nil, assertFailed("limit <= 1000: ")
+	}
+	return n.namesFrom(lowerBound, limit), nil
+}
+
+func listAccounts(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var args struct {
+		Start string `json:"start"`
+		Limit int    `json:"limit"`
+		Order string `json:"order"`
+	}
+	if err := named(params, &args); err != nil {
+		return nil, err
+	}
+	if args.Order != "by_name" {
+		return nil, invalidParams("only order by_name is supported")
+	}
+	if args.Limit > maxPage {
+		return nil, assertFailed("limit <= 1000: ")
+	}
+	return map[string]interface{}{"accounts": n.findAccounts(n.namesFrom(args.Start, args.Limit))}, nil
+}
+
+// namesFrom returns up to limit account names from lowerBound on.
+func (n *Node) namesFrom(lowerBound string, limit int) []string {
+	names := n.sortedNames()
+	names = names[sort.SearchStrings(names, lowerBound):]
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
+
+func getFollowCount(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var account string
+	if err := positional(params, 1, &account); err != nil {
+		return nil, err
+	}
+	followers, following := 0, 0
+	for _, f := range n.follows {
+		if !f.is("blog") {
+			continue
+		}
+		if f.following == account {
+			followers++
+		}
+		if f.follower == account {
+			following++
+		}
+	}
+	return map[string]interface{}{
+		"account":         account,
+		"follower_count":  followers,
+		"following_count": following,
+	}, nil
+}
+
+func getFollowers(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	return n.followPage(params, func(f *follow) (string, string) { return f.following, f.follower })
+}
+
+func getFollowing(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	return n.followPage(params, func(f *follow) (string, string) { return f.follower, f.following })
+}
+
+// followPage answers get_followers and get_following: side returns the
+// account a relation is looked up by and the name it is ordered by.
+func (n *Node) followPage(params json.RawMessage, side func(*follow) (string, string)) (interface{}, *rpcError) {
+	var account, start, followType string
+	var limit int
+	if err := positional(params, 4, &account, &start, &followType, &limit); err != nil {
+		return nil, err
+	}
+	if limit > maxPage {
+		return nil, assertFailed("limit <= 1000: ")
+	}
+	var page []*follow
+	for _, f := range n.follows {
+		if by, key := side(f); by == account && key >= start && f.is(followType) {
+			page = append(page, f)
+		}
+	}
+	sort.Slice(page, func(i, j int) bool {
+		_, a := side(page[i])
+		_, b := side(page[j])
+		return a < b
+	})
+	if len(page) > limit {
+		page = page[:limit]
+	}
+	result := make([]interface{}, len(page))
+	for i, f := range page {
+		result[i] = map[string]interface{}{"follower": f.follower, "following": f.following, "what": f.what}
+	}
+	return result, nil
+}
+
+func (f *follow) is(followType string) bool {
+	for _, what := range f.what {
+		if what == followType {
+			return true
+		}
+	}
+	return false
+}
+
+func getAccountHistory(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var account string
+	var start int64
+	var limit int
+	var low, high uint64
+	if err := positional(params, 3, &account, &start, &limit, &low, &high); err != nil {
+		return nil, err
+	}
+	if limit > maxPage {
+		return nil, assertFailed("limit <= 1000: ")
+	}
+	if start >= 0 && start < int64(limit) {
+		return nil, assertFailed("start must be greater than limit: ")
+	}
+	entries := n.history[account]
+	if start < 0 || start >= int64(len(entries)) {
+		start = int64(len(entries)) - 1
+	}
+
+	// A page covers indices [start-limit, start]; with a filter it holds the
+	// limit+1 newest matches from start down instead.
+	var page []*historyEntry
+	for i := start; i >= 0; i-- {
+		e := entries[i]
+		if low == 0 && high == 0 {
+			if i < start-int64(limit) {
+				break
+			}
+		} else {
+			if len(page) > limit {
+				break
+			}
+			if !matchesFilter(e.op.Type, low, high) {
+				continue
+			}
+		}
+		page = append(page, e)
+	}
+
+	result := make([]interface{}, 0, len(page))
+	for i := len(page) - 1; i >= 0; i-- {
+		e := page[i]
+		result = append(result, []interface{}{e.index, map[string]interface{}{
+			"trx_id":       e.trxID,
+			"block":        e.block,
+			"trx_in_block": 0,
+			"op_in_trx":    0,
+			"virtual_op":   0,
+			"timestamp":    e.timestamp.Format(timeLayout),
+			"op":           e.op,
+		}})
+	}
+	return result, nil
+}
+
+// matchesFilter reports whether the history filter masks low and high
+// select opType.
+func matchesFilter(opType string, low, high uint64) bool {
+	for id, name := range consts.OPERATION_NAMES {
+		if name != opType {
+			continue
+		}
+		if id < 64 {
+			return low&(1<<uint(id)) != 0
+		}
+		return high&(1<<uint(id-64)) != 0
+	}
+	return false
+}
+
+func condenserGetOrderBook(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var limit int
+	if err := positional(params, 1, &limit); err != nil {
+		return nil, err
+	}
+	return n.orderBook(limit)
+}
+
+func databaseGetOrderBook(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	var args struct {
+		Limit int `json:"limit"`
+	}
+	if err := named(params, &args); err != nil {
+		return nil, err
+	}
+	return n.orderBook(args.Limit)
+}
+
+// orderBook lists up to limit orders on each side, best price first.
+func (n *Node) orderBook(limit int) (interface{}, *rpcError) {
+	if limit > 500 {
+		return nil, assertFailed("limit <= 500: ")
+	}
+	var bids, asks []*order
+	for _, o := range n.orders {
+		if o.sell.symbol == "SBD" {
+			bids = append(bids, o)
+		} else {
+			asks = append(asks, o)
+		}
+	}
+	// Both sides are ordered by SBD per STEEM: bids highest first, asks
+	// lowest first.
+	sort.SliceStable(bids, func(i, j int) bool {
+		return bids[i].sell.amount*bids[j].receive.amount > bids[j].sell.amount*bids[i].receive.amount
+	})
+	sort.SliceStable(asks, func(i, j int) bool {
+		return asks[i].receive.amount*asks[j].sell.amount < asks[j].receive.amount*asks[i].sell.amount
+	})
+	side := func(orders []*order) []interface{} {
+		if len(orders) > limit {
+			orders = orders[:limit]
+		}
+		entries := make([]interface{}, len(orders))
+		for i, o := range orders {
+			steem, sbd := o.receive, o.sell
+			if o.sell.symbol == "STEEM" {
+				steem, sbd = o.sell, o.receive
+			}
+			entries[i] = map[string]interface{}{
+				"order_price": price{base: o.sell, quote: o.receive},
+				"real_price":  fmt.Sprintf("%.17f", float64(sbd.amount)/float64(steem.amount)),
+				"steem":       steem.amount,
+				"sbd":         sbd.amount,
+				"created":     o.created.Format(timeLayout),
+			}
+		}
+		return entries
+	}
+	return map[string]interface{}{"bids": side(bids), "asks": side(asks)}, nil
+}
+
+func condenserGetFeedHistory(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	if err := positional(params, 0); err != nil {
+		return nil, err
+	}
+	return n.feedHistory(), nil
+}
+
+func databaseGetFeedHistory(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	return n.feedHistory(), nil
+}
+
+func (n *Node) feedHistory() map[string]interface{} {
+	current := price{base: asset{symbol: "SBD"}, quote: asset{symbol: "STEEM"}}
+	if len(n.feed) > 0 {
+		current = n.feed[len(n.feed)-1]
+	}
+	return map[string]interface{}{
+		"id":                     0,
+		"current_median_history": current,
+		"price_history":          append([]price{}, n.feed...),
+	}
+}
+
+func broadcastTransaction(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	if _, err := n.accept(params); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{}, nil
+}
+
+// broadcastTransactionSynchronous includes the transaction in a new block
+// right away, as steemd answers once the transaction is in a block.
+func broadcastTransactionSynchronous(n *Node, params json.RawMessage) (interface{}, *rpcError) {
+	tx, err := n.accept(params)
+	if err != nil {
+		return nil, err
+	}
+	trxNum := len(n.pending) - 1
+	b := n.produceBlock()
+	return map[string]interface{}{
+		"id":        tx.ID,
+		"block_num": b.Num,
+		"trx_num":   trxNum,
+		"expired":   false,
+	}, nil
+}
+
+// accept validates a broadcast transaction, queues it for the next block and
+// records it.
+func (n *Node) accept(params json.RawMessage) (*Transaction, *rpcError) {
+	var raw json.RawMessage
+	if err := positional(params, 1, &raw); err != nil {
+		return nil, err
+	}
+	tx, err := newTransaction(raw)
+	if err != nil {
+		return nil, invalidParams(err.Error())
+	}
+	for _, seen := range n.broadcasts {
+		if seen.ID == tx.ID {
+			return nil, assertFailed("Duplicate transaction check failed")
+		}
+	}
+	expiration, _ := tx.fields["expiration"].(string)
+	exp, err := time.Parse(timeLayout, expiration)
+	if err != nil {
+		return nil, invalidParams("invalid expiration")
+	}
+	if !n.head().Timestamp.Before(exp) {
+		return nil, assertFailed("now < trx.expiration: ")
+	}
+	n.pending = append(n.pending, tx)
+	n.broadcasts = append(n.broadcasts, tx)
+	return tx, nil
+}
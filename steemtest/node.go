@@ -0,0 +1,613 @@
+// Package steemtest provides an in-memory fake Steem node for tests. A Node
+// serves the condenser_api and database_api methods the SDK uses from state
+// the test registers (accounts, follow relations, account history, market
+// orders and the price feed), records the transactions broadcast to it and
+// produces blocks on demand:
+//
+//	node := steemtest.NewNode()
+//	defer node.Close()
+//	node.AddAccount("alice", map[string]interface{}{
+//		"posting": steemtest.KeyAuthority("STM7jNh5ejQoqHqWcGWFJ1v4F5CzsG3EiBuz1VooCng1cH5QpJD27"),
+//	})
+//	node.Follow("bob", "alice")
+//
+//	a := api.NewAPI(node.URL)
+//	followers, err := a.GetFollowers("alice", "", "blog", 10)
+//
+// SetError, SetCallError, FailHTTP, DropAnswers and SetLatency inject the
+// faults of a real node or of the proxies in front of it.
+//
+// The package does not import the SDK's api package, so api's own tests can
+// use it as well as any downstream code.
+package steemtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/steemit/steemutil/transaction"
+)
+
+// BlockInterval is the time between two blocks of a Node.
+const BlockInterval = 3 * time.Second
+
+// timeLayout is the zoneless UTC format steemd uses for timestamps.
+const timeLayout = "2006-01-02T15:04:05"
+
+// Node is a fake steemd serving JSON-RPC over HTTP at URL. It starts with a
+// single block; further blocks are produced by AdvanceBlocks and by
+// synchronous broadcasts only. All methods are safe for concurrent use.
+//
+// The node checks neither signatures nor authorities, and broadcast
+// operations are not applied to its state: a transfer moves no funds and a
+// follow custom_json adds no follow relation.
+type Node struct {
+	// URL is the node's HTTP endpoint, to be passed to api.NewAPI.
+	URL string
+
+	server *httptest.Server
+	closed chan struct{}
+
+	mu             sync.Mutex
+	start          time.Time
+	lag            uint32
+	reverseBatches bool
+	maxBatchSize   int
+	blocks         []*Block // blocks[i] is block i+1
+	pending        []*Transaction
+	pendingVirtual []Op
+	accounts       map[string]map[string]interface{}
+	follows        []*follow
+	history        map[string][]*historyEntry
+	orders         []*order
+	feed           []price
+	broadcasts     []*Transaction
+	failures       []*failure
+	requests       []Request
+	posts          int
+	latency        time.Duration
+	failStatus     int
+	failPosts      int
+	dropPosts      int
+}
+
+// Option configures a Node.
+type Option func(*Node)
+
+// WithStartTime sets the timestamp of the first block, 2016-03-24 16:05:00
+// UTC (the Steem genesis) by default.
+func WithStartTime(t time.Time) Option {
+	return func(n *Node) {
+		n.start = t.UTC().Truncate(time.Second)
+	}
+}
+
+// WithIrreversibleLag keeps the last irreversible block lag blocks behind the
+// head block. By default every block is irreversible as soon as it is
+// produced.
+func WithIrreversibleLag(lag uint32) Option {
+	return func(n *Node) {
+		n.lag = lag
+	}
+}
+
+// WithReversedBatches answers the calls of a batch in reverse order, which
+// JSON-RPC 2.0 allows, so a test can check responses are matched by id.
+func WithReversedBatches() Option {
+	return func(n *Node) {
+		n.reverseBatches = true
+	}
+}
+
+// WithMaxBatchSize rejects batches of more than size calls with a single
+// error object, as API gateways such as jussi do. None of the calls is
+// handled.
+func WithMaxBatchSize(size int) Option {
+	return func(n *Node) {
+		n.maxBatchSize = size
+	}
+}
+
+// NewNode starts a Node. Close it when the test is done.
+func NewNode(opts ...Option) *Node {
+	n := &Node{
+		closed:   make(chan struct{}),
+		start:    time.Date(2016, 3, 24, 16, 5, 0, 0, time.UTC),
+		accounts: make(map[string]map[string]interface{}),
+		history:  make(map[string][]*historyEntry),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.produceBlock()
+	n.server = httptest.NewServer(n)
+	n.URL = n.server.URL
+	return n
+}
+
+// Close shuts the node's HTTP server down, abandoning the requests delayed
+// by SetLatency.
+func (n *Node) Close() {
+	close(n.closed)
+	n.server.Close()
+}
+
+// Op is an operation in the condenser_api [type, value] form, e.g.
+//
+//	steemtest.Op{Type: "vote", Value: map[string]interface{}{"voter": "alice", ...}}
+type Op struct {
+	Type  string
+	Value map[string]interface{}
+}
+
+func (op Op) MarshalJSON() ([]byte, error) {
+	value := op.Value
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+	return json.Marshal([]interface{}{op.Type, value})
+}
+
+func (op *Op) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil || len(pair) != 2 {
+		return errInvalidOp
+	}
+	if err := json.Unmarshal(pair[0], &op.Type); err != nil {
+		return errInvalidOp
+	}
+	return json.Unmarshal(pair[1], &op.Value)
+}
+
+// Transaction is a transaction the node received or built.
+type Transaction struct {
+	// ID is the transaction id, as broadcast.Broadcast computes it.
+	ID         string
+	Operations []Op
+	// Raw is the transaction JSON as broadcast, or as built by
+	// PushTransaction.
+	Raw json.RawMessage
+	// BlockNum is the block including the transaction, 0 while it waits
+	// for the next block.
+	BlockNum uint32
+
+	fields map[string]interface{}
+}
+
+// Block is a block produced by the node.
+type Block struct {
+	Num          uint32
+	ID           string
+	Previous     string
+	Timestamp    time.Time
+	Witness      string
+	Transactions []Transaction
+	// VirtualOps are the virtual operations produced with the block, listed
+	// by get_ops_in_block only.
+	VirtualOps []Op
+}
+
+// Request is a JSON-RPC call the node received. Method is the dotted
+// "api.method" name, also for calls made in the legacy "call" form.
+type Request struct {
+	Method string
+	Params json.RawMessage
+	// Legacy reports a call made in the legacy "call" form; Params are then
+	// the params of the inner call.
+	Legacy bool
+	// Header is the header of the HTTP request carrying the call.
+	Header http.Header
+}
+
+type order struct {
+	owner         string
+	created       time.Time
+	sell, receive asset
+}
+
+type price struct {
+	base, quote asset
+}
+
+type failure struct {
+	method string
+	params interface{} // decoded params to match, nil for every call
+	err    *rpcError
+}
+
+type follow struct {
+	follower, following string
+	what                []string
+}
+
+type historyEntry struct {
+	index     int64
+	block     uint32
+	timestamp time.Time
+	trxID     string
+	op        Op
+}
+
+// HistoryEntry is an account history entry for AddHistoryEntry. A zero
+// Block, Timestamp or TrxID stands for the head block, its time and the
+// all-zero id of virtual operations.
+type HistoryEntry struct {
+	Op        Op
+	Block     uint32
+	Timestamp time.Time
+	TrxID     string
+}
+
+// AddAccount registers an account, replacing any account of the same name.
+// fields are set over a default account object with empty authorities and
+// zero balances, so a test only sets what it checks.
+func (n *Node) AddAccount(name string, fields map[string]interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id := len(n.accounts)
+	if old, ok := n.accounts[name]["id"].(int); ok {
+		id = old
+	}
+	account := map[string]interface{}{
+		"id":                       id,
+		"name":                     name,
+		"owner":                    KeyAuthority(),
+		"active":                   KeyAuthority(),
+		"posting":                  KeyAuthority(),
+		"memo_key":                 "STM1111111111111111111111111111111114T1Anm",
+		"json_metadata":            "",
+		"posting_json_metadata":    "",
+		"created":                  n.start.Format(timeLayout),
+		"balance":                  "0.000 STEEM",
+		"savings_balance":          "0.000 STEEM",
+		"sbd_balance":              "0.000 SBD",
+		"savings_sbd_balance":      "0.000 SBD",
+		"vesting_shares":           "0.000000 VESTS",
+		"delegated_vesting_shares": "0.000000 VESTS",
+		"received_vesting_shares":  "0.000000 VESTS",
+		"voting_power":             10000,
+		"reputation":               0,
+		"post_count":               0,
+	}
+	for key, value := range fields {
+		account[key] = value
+	}
+	n.accounts[name] = account
+}
+
+// KeyAuthority returns an authority satisfied by a signature of any one of
+// pubKeys, for the owner, active and posting fields of AddAccount.
+func KeyAuthority(pubKeys ...string) map[string]interface{} {
+	keyAuths := make([]interface{}, 0, len(pubKeys))
+	for _, key := range pubKeys {
+		keyAuths = append(keyAuths, []interface{}{key, 1})
+	}
+	return map[string]interface{}{
+		"weight_threshold": 1,
+		"account_auths":    []interface{}{},
+		"key_auths":        keyAuths,
+	}
+}
+
+// Follow records that follower follows following. what lists the follow
+// types, ["blog"] if empty; ["ignore"] mutes. Following again replaces the
+// types.
+func (n *Node) Follow(follower, following string, what ...string) {
+	if len(what) == 0 {
+		what = []string{"blog"}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, f := range n.follows {
+		if f.follower == follower && f.following == following {
+			f.what = what
+			return
+		}
+	}
+	n.follows = append(n.follows, &follow{follower: follower, following: following, what: what})
+}
+
+// AddOrder places a limit order of owner on the internal market, selling
+// the sell amount for the receive amount, e.g. "1.000 SBD" for "2.000 STEEM".
+// Orders selling SBD are the bids of get_order_book and orders selling STEEM
+// its asks. It panics on an amount that is not a STEEM or SBD asset string.
+func (n *Node) AddOrder(owner, sell, receive string) {
+	o := &order{owner: owner, sell: mustAsset(sell), receive: mustAsset(receive)}
+	if o.sell.symbol == o.receive.symbol {
+		panic("steemtest: an order must trade STEEM for SBD")
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	o.created = n.head().Timestamp
+	n.orders = append(n.orders, o)
+}
+
+// AddFeedPrice appends a median price, e.g. "0.500 SBD" for "1.000 STEEM",
+// to the feed history; the last one added is the current median.
+func (n *Node) AddFeedPrice(base, quote string) {
+	p := price{base: mustAsset(base), quote: mustAsset(quote)}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.feed = append(n.feed, p)
+}
+
+// AddHistory appends op to the account history of account, stamped with the
+// head block, and returns its index in that history.
+func (n *Node) AddHistory(account string, op Op) int64 {
+	return n.AddHistoryEntry(account, HistoryEntry{Op: op})
+}
+
+// AddHistoryEntry appends e to the account history of account and returns
+// its index in that history. It lets a test lay out a history spanning more
+// time than the node's blocks do.
+func (n *Node) AddHistoryEntry(account string, e HistoryEntry) int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	head := n.head()
+	entry := &historyEntry{block: e.Block, timestamp: e.Timestamp, trxID: e.TrxID, op: e.Op}
+	if entry.block == 0 {
+		entry.block = head.Num
+	}
+	if entry.timestamp.IsZero() {
+		entry.timestamp = head.Timestamp
+	}
+	if entry.trxID == "" {
+		entry.trxID = zeroID
+	}
+	entries := n.history[account]
+	entry.index = int64(len(entries))
+	n.history[account] = append(entries, entry)
+	return entry.index
+}
+
+// PushTransaction queues a transaction made of ops for the next block and
+// returns its id. Its reference block is the head block; it carries no
+// signatures.
+func (n *Node) PushTransaction(ops ...Op) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	head := n.head()
+	refBlockPrefix, _ := transaction.RefBlockPrefix(head.ID)
+	raw, _ := json.Marshal(map[string]interface{}{
+		"ref_block_num":    head.Num & 0xFFFF,
+		"ref_block_prefix": refBlockPrefix,
+		"expiration":       head.Timestamp.Add(10 * time.Minute).Format(timeLayout),
+		"operations":       ops,
+		"extensions":       []interface{}{},
+		"signatures":       []interface{}{},
+	})
+	tx, _ := newTransaction(raw)
+	n.pending = append(n.pending, tx)
+	return tx.ID
+}
+
+// PushVirtualOp queues a virtual operation, such as a producer_reward, for
+// the next block.
+func (n *Node) PushVirtualOp(op Op) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pendingVirtual = append(n.pendingVirtual, op)
+}
+
+// AdvanceBlocks produces count blocks, the first of them including every
+// pending transaction and virtual operation, and returns the new head block number.
+func (n *Node) AdvanceBlocks(count int) uint32 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := 0; i < count; i++ {
+		n.produceBlock()
+	}
+	return n.head().Num
+}
+
+// HeadBlockNum returns the number of the newest block.
+func (n *Node) HeadBlockNum() uint32 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.head().Num
+}
+
+// Block returns block num, if it was produced.
+func (n *Node) Block(num uint32) (Block, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	b := n.block(num)
+	if b == nil {
+		return Block{}, false
+	}
+	return *b, true
+}
+
+// Broadcasts returns the transactions accepted by broadcast_transaction and
+// broadcast_transaction_synchronous, oldest first.
+func (n *Node) Broadcasts() []Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	txs := make([]Transaction, len(n.broadcasts))
+	for i, tx := range n.broadcasts {
+		txs[i] = *tx
+	}
+	return txs
+}
+
+// Requests returns the calls the node received, oldest first. A batch
+// contributes one Request per call.
+func (n *Node) Requests() []Request {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Request(nil), n.requests...)
+}
+
+// Posts returns the number of HTTP requests the node received, a batch
+// counting once. Requests failed by FailHTTP count too.
+func (n *Node) Posts() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.posts
+}
+
+// SetError makes every following call of method, e.g.
+// "condenser_api.broadcast_transaction_synchronous", fail with the given
+// JSON-RPC error until ClearError is called. steemd reports assertion
+// failures with code -32000 and the assertion in the message.
+func (n *Node) SetError(method string, code int, message string) {
+	n.setError(method, nil, code, message)
+}
+
+// SetCallError is like SetError but fails only the calls of method made with
+// params, compared as JSON, e.g. get_block for a single block:
+//
+//	node.SetCallError("condenser_api.get_block", []interface{}{12}, -32000, "boom")
+func (n *Node) SetCallError(method string, params interface{}, code int, message string) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		panic(err)
+	}
+	var decoded interface{}
+	json.Unmarshal(raw, &decoded)
+	n.setError(method, decoded, code, message)
+}
+
+func (n *Node) setError(method string, params interface{}, code int, message string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, f := range n.failures {
+		if f.method == method && reflect.DeepEqual(f.params, params) {
+			f.err = &rpcError{Code: code, Message: message}
+			return
+		}
+	}
+	n.failures = append(n.failures, &failure{method: method, params: params, err: &rpcError{Code: code, Message: message}})
+}
+
+// ClearError undoes SetError and SetCallError for method.
+func (n *Node) ClearError(method string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	kept := n.failures[:0]
+	for _, f := range n.failures {
+		if f.method != method {
+			kept = append(kept, f)
+		}
+	}
+	n.failures = kept
+}
+
+// SetLatency delays the handling of every following HTTP request by d, as a
+// slow or overloaded node would. A request whose client gives up, or whose
+// node is closed, during the delay is not handled.
+func (n *Node) SetLatency(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.latency = d
+}
+
+// FailHTTP answers the next count HTTP requests with status and an empty
+// body, as a proxy in front of a troubled node does. The calls they carry
+// are not handled.
+func (n *Node) FailHTTP(status, count int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.failStatus, n.failPosts = status, count
+}
+
+// DropAnswers handles the next count HTTP requests but closes their
+// connections instead of answering, as when a node or proxy fails after a
+// call was applied.
+func (n *Node) DropAnswers(count int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dropPosts = count
+}
+
+func (n *Node) head() *Block {
+	return n.blocks[len(n.blocks)-1]
+}
+
+func (n *Node) block(num uint32) *Block {
+	if num == 0 || int(num) > len(n.blocks) {
+		return nil
+	}
+	return n.blocks[num-1]
+}
+
+func (n *Node) lastIrreversible() uint32 {
+	head := n.head().Num
+	if head <= n.lag {
+		return 1
+	}
+	return head - n.lag
+}
+
+// produceBlock appends a block holding the pending transactions.
+func (n *Node) produceBlock() *Block {
+	b := &Block{
+		Num:       uint32(len(n.blocks)) + 1,
+		Previous:  "0000000000000000000000000000000000000000",
+		Timestamp: n.start.Add(time.Duration(len(n.blocks)) * BlockInterval),
+		Witness:   "initminer",
+	}
+	if len(n.blocks) > 0 {
+		b.Previous = n.head().ID
+	}
+	h := sha256.New()
+	h.Write([]byte(b.Previous))
+	for _, tx := range n.pending {
+		tx.BlockNum = b.Num
+		b.Transactions = append(b.Transactions, *tx)
+		h.Write([]byte(tx.ID))
+	}
+	n.pending = nil
+	b.VirtualOps, n.pendingVirtual = n.pendingVirtual, nil
+	// Like steemd's, the id starts with the block number.
+	b.ID = hex.EncodeToString([]byte{byte(b.Num >> 24), byte(b.Num >> 16), byte(b.Num >> 8), byte(b.Num)}) +
+		hex.EncodeToString(h.Sum(nil))[:32]
+	n.blocks = append(n.blocks, b)
+	return b
+}
+
+// newTransaction parses a transaction in its condenser_api JSON form. The id
+// is computed from the serialized transaction like steemd does when
+// steemutil knows every operation, and from the JSON otherwise.
+func newTransaction(raw json.RawMessage) (*Transaction, error) {
+	var body struct {
+		Operations []Op `json:"operations"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return nil, err
+	}
+	tx := &Transaction{Operations: body.Operations, Raw: raw, fields: fields}
+	var parsed transaction.Transaction
+	if err := json.Unmarshal(raw, &parsed); err == nil {
+		tx.ID = transaction.NewSignedTransaction(&parsed).ID()
+	}
+	if tx.ID == "" {
+		sum := sha256.Sum256(raw)
+		tx.ID = hex.EncodeToString(sum[:20])
+	}
+	return tx, nil
+}
+
+// sortedNames returns the registered account names in lexical order.
+func (n *Node) sortedNames() []string {
+	names := make([]string, 0, len(n.accounts))
+	for name := range n.accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,440 @@
+package steemtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/steemit/steemgosdk/api"
+	"github.com/steemit/steemgosdk/broadcast"
+	"github.com/steemit/steemutil/protocol"
+	protocolapi "github.com/steemit/steemutil/protocol/api"
+	"github.com/steemit/steemutil/rpc"
+)
+
+const (
+	testWIF    = "5JWHY5DxTF6qN5grTtChDCYBmWHfY9zaSsw4CxEKN5eZpH9iBma"
+	testPubKey = "STM7jNh5ejQoqHqWcGWFJ1v4F5CzsG3EiBuz1VooCng1cH5QpJD27"
+)
+
+func TestNode_Accounts(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	for _, name := range []string{"carol", "alice", "bob", "dave"} {
+		node.AddAccount(name, nil)
+	}
+	node.AddAccount("alice", map[string]interface{}{"balance": "12.000 STEEM"})
+	a := api.NewAPI(node.URL)
+
+	accounts, err := a.GetAccounts([]string{"bob", "ghost", "alice"})
+	if err != nil {
+		t.Fatalf("GetAccounts failed: %v", err)
+	}
+	if len(accounts) != 2 || accounts[0].Name != "bob" || accounts[1].Name != "alice" || accounts[1].Balance != "12.000 STEEM" {
+		t.Errorf("unexpected accounts %+v", accounts)
+	}
+
+	names, err := a.LookupAccounts("b", 2)
+	if err != nil {
+		t.Fatalf("LookupAccounts failed: %v", err)
+	}
+	if len(names) != 2 || names[0] != "bob" || names[1] != "carol" {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	var found struct {
+		Accounts []struct {
+			Name string `json:"name"`
+		} `json:"accounts"`
+	}
+	if err := a.CallNamed("database_api", "find_accounts", map[string]interface{}{"accounts": []string{"dave"}}, &found); err != nil {
+		t.Fatalf("find_accounts failed: %v", err)
+	}
+	if len(found.Accounts) != 1 || found.Accounts[0].Name != "dave" {
+		t.Errorf("unexpected find_accounts result %+v", found)
+	}
+
+	var all []string
+	err = a.EachAccount(context.Background(), "", func(account *protocolapi.ExtendedAccount) error {
+		all = append(all, account.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachAccount failed: %v", err)
+	}
+	if len(all) != 4 || all[0] != "alice" || all[3] != "dave" {
+		t.Errorf("unexpected accounts %v", all)
+	}
+}
+
+func TestNode_VerifySignedRequest(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	node.AddAccount("alice", map[string]interface{}{"posting": KeyAuthority(testPubKey)})
+
+	signed, err := rpc.Sign(&rpc.RpcRequest{Method: "hello", Params: []interface{}{1}, ID: 1}, "alice", []string{testWIF})
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, account, err := api.NewAPI(node.URL).VerifySignedRequest(signed); err != nil || account != "alice" {
+		t.Errorf("VerifySignedRequest = %q, %v", account, err)
+	}
+}
+
+func TestNode_Follows(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	for _, follower := range []string{"erin", "bob", "dave", "carol"} {
+		node.Follow(follower, "alice")
+	}
+	node.Follow("mallory", "alice", "ignore")
+	node.Follow("alice", "bob")
+	a := api.NewAPI(node.URL)
+
+	var followers []string
+	it := a.IterateFollowers(context.Background(), "alice", "blog", 2)
+	for it.Next() {
+		followers = append(followers, it.Entry().Follower)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("IterateFollowers failed: %v", err)
+	}
+	if want := []string{"bob", "carol", "dave", "erin"}; !equal(followers, want) {
+		t.Errorf("got followers %v, want %v", followers, want)
+	}
+
+	muted, err := a.GetFollowers("alice", "", "ignore", 10)
+	if err != nil || len(muted) != 1 || muted[0].Follower != "mallory" {
+		t.Errorf("unexpected ignore followers %+v, %v", muted, err)
+	}
+	following, err := a.GetFollowing("alice", "", "blog", 10)
+	if err != nil || len(following) != 1 || following[0].Following != "bob" {
+		t.Errorf("unexpected following %+v, %v", following, err)
+	}
+	count, err := a.GetFollowCount("alice")
+	if err != nil || count.FollowerCount != 4 || count.FollowingCount != 1 {
+		t.Errorf("unexpected follow count %+v, %v", count, err)
+	}
+}
+
+func TestNode_AccountHistory(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	for i := 0; i < 7; i++ {
+		opType := "vote"
+		if i%3 == 0 {
+			opType = "transfer"
+		}
+		node.AddHistory("alice", Op{Type: opType, Value: map[string]interface{}{"n": i}})
+		node.AdvanceBlocks(1)
+	}
+	a := api.NewAPI(node.URL)
+
+	var indices []int64
+	it := a.IterateAccountHistory(context.Background(), "alice", api.AccountHistoryOptions{PageSize: 2})
+	for it.Next() {
+		indices = append(indices, it.Entry().Index)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("IterateAccountHistory failed: %v", err)
+	}
+	if len(indices) != 7 || indices[0] != 6 || indices[6] != 0 {
+		t.Errorf("unexpected indices %v", indices)
+	}
+
+	var transfers []int64
+	it = a.IterateAccountHistory(context.Background(), "alice", api.AccountHistoryOptions{PageSize: 2, Types: []string{"transfer"}})
+	for it.Next() {
+		e := it.Entry()
+		if e.Op.Type != "transfer" || e.BlockNumber != uint32(e.Index)+1 {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		transfers = append(transfers, e.Index)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("filtered IterateAccountHistory failed: %v", err)
+	}
+	if want := []int64{6, 3, 0}; len(transfers) != 3 || transfers[0] != 6 || transfers[2] != 0 {
+		t.Errorf("got transfers %v, want %v", transfers, want)
+	}
+}
+
+func TestNode_AddHistoryEntry(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	node.AddHistoryEntry("alice", HistoryEntry{Op: Op{Type: "vote"}, Block: 42, Timestamp: at, TrxID: "abc"})
+	node.AddHistory("alice", Op{Type: "transfer"})
+
+	history, err := api.NewAPI(node.URL).GetAccountHistory("alice", -1, 1)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("expected 2 entries, got %v, %v", history, err)
+	}
+	if e := history[0]; e.BlockNumber != 42 || e.Timestamp != "2026-01-01T00:00:00" || e.TransactionID != "abc" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := history[1]; e.BlockNumber != node.HeadBlockNum() || e.TransactionID != zeroID {
+		t.Errorf("expected the head block and zero id, got %+v", e)
+	}
+}
+
+func TestNode_Blocks(t *testing.T) {
+	node := NewNode(WithIrreversibleLag(2))
+	defer node.Close()
+	trxID := node.PushTransaction(Op{Type: "vote", Value: map[string]interface{}{"voter": "alice", "author": "bob", "permlink": "post", "weight": 10000}})
+	node.PushVirtualOp(Op{Type: "producer_reward", Value: map[string]interface{}{"producer": "initminer", "vesting_shares": "1.000000 VESTS"}})
+	if head := node.AdvanceBlocks(4); head != 5 {
+		t.Fatalf("head = %d, want 5", head)
+	}
+	a := api.NewAPI(node.URL)
+
+	dgp, err := a.GetDynamicGlobalProperties()
+	if err != nil {
+		t.Fatalf("GetDynamicGlobalProperties failed: %v", err)
+	}
+	if dgp.HeadBlockNumber != 5 || dgp.LastIrreversibleBlockNum != 3 {
+		t.Errorf("unexpected dgp %+v", dgp)
+	}
+
+	blocks, err := a.GetBlocks(1, 5)
+	if err != nil {
+		t.Fatalf("GetBlocks failed: %v", err)
+	}
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].Block.Previous != blocks[i-1].Block.BlockId {
+			t.Errorf("block %d does not link to block %d", blocks[i].BlockNum, blocks[i-1].BlockNum)
+		}
+	}
+	if txs := blocks[1].Block.Transactions; len(txs) != 1 || txs[0].TransactionId != trxID {
+		t.Errorf("unexpected transactions in block 2: %+v", txs)
+	}
+
+	ops, err := a.GetOpsInBlock(2, false)
+	if err != nil {
+		t.Fatalf("GetOpsInBlock failed: %v", err)
+	}
+	if len(ops) != 2 || ops[0].TransactionID != trxID || ops[0].Operation.Type() != "vote" ||
+		ops[1].VirtualOperation != 1 || ops[1].Operation.Type() != "producer_reward" {
+		t.Errorf("unexpected ops %+v", ops)
+	}
+	if ops, err := a.GetOpsInBlock(2, true); err != nil || len(ops) != 1 {
+		t.Errorf("expected only the virtual op, got %+v, %v", ops, err)
+	}
+
+	if block, err := a.GetBlock(6); err != nil || block != nil && block.BlockId != "" {
+		t.Errorf("GetBlock past head = %+v, %v", block, err)
+	}
+}
+
+func TestNode_Broadcast(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	b := broadcast.NewBroadcast(node.URL)
+
+	vote := &protocol.VoteOperation{Voter: "alice", Author: "bob", Permlink: "post", Weight: 10000}
+	if _, err := b.SendWith(vote, testWIF); err != nil {
+		t.Fatalf("SendWith failed: %v", err)
+	}
+	second := &protocol.VoteOperation{Voter: "alice", Author: "carol", Permlink: "post", Weight: 5000}
+	trxID, err := b.SendWithAsync(second, testWIF)
+	if err != nil {
+		t.Fatalf("SendWithAsync failed: %v", err)
+	}
+
+	sent := node.Broadcasts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 broadcasts, got %d", len(sent))
+	}
+	if sent[0].BlockNum != 2 || sent[0].Operations[0].Type != "vote" || sent[0].Operations[0].Value["author"] != "bob" {
+		t.Errorf("unexpected sync broadcast %+v", sent[0])
+	}
+	if sent[1].ID != trxID || sent[1].BlockNum != 0 {
+		t.Errorf("unexpected async broadcast %+v", sent[1])
+	}
+	node.AdvanceBlocks(1)
+	if block, _ := node.Block(3); len(block.Transactions) != 1 || block.Transactions[0].ID != trxID {
+		t.Errorf("async broadcast not in block 3: %+v", block)
+	}
+
+	err = b.BroadcastAsync([]interface{}{sent[0].Raw})
+	if !errors.Is(err, api.ErrDuplicateTransaction) {
+		t.Errorf("expected duplicate transaction error, got %v", err)
+	}
+}
+
+func TestNode_SetErrorAndRequests(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	node.AddAccount("alice", nil)
+	node.SetError("condenser_api.broadcast_transaction_synchronous", -32000, "missing required posting authority")
+	b := broadcast.NewBroadcast(node.URL, api.WithLegacyCallForm())
+
+	vote := &protocol.VoteOperation{Voter: "alice", Author: "bob", Permlink: "post", Weight: 10000}
+	if _, err := b.SendWith(vote, testWIF); !errors.Is(err, api.ErrMissingAuthority) {
+		t.Errorf("expected missing authority error, got %v", err)
+	}
+	node.ClearError("condenser_api.broadcast_transaction_synchronous")
+	if _, err := b.SendWith(vote, testWIF); err != nil {
+		t.Errorf("SendWith failed after ClearError: %v", err)
+	}
+
+	a := api.NewAPI(node.URL)
+	var accounts []*protocolapi.ExtendedAccount
+	var dgp map[string]interface{}
+	batch := a.NewBatch().
+		Add("condenser_api", "get_accounts", []interface{}{[]string{"alice"}}, &accounts).
+		Add("condenser_api", "get_dynamic_global_properties", nil, &dgp)
+	if err := batch.Do(); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if len(accounts) != 1 || dgp["head_block_number"] == nil {
+		t.Errorf("unexpected batch results %+v %+v", accounts, dgp)
+	}
+
+	var methods []string
+	for _, req := range node.Requests() {
+		methods = append(methods, req.Method)
+		if !req.Legacy && req.Method == "condenser_api.broadcast_transaction_synchronous" {
+			t.Errorf("%s not recorded as a legacy call", req.Method)
+		}
+	}
+	want := []string{
+		"condenser_api.get_dynamic_global_properties",
+		"condenser_api.get_block",
+		"condenser_api.broadcast_transaction_synchronous",
+		"condenser_api.get_dynamic_global_properties",
+		"condenser_api.get_block",
+		"condenser_api.broadcast_transaction_synchronous",
+		"condenser_api.get_accounts",
+		"condenser_api.get_dynamic_global_properties",
+	}
+	if !equal(methods, want) {
+		t.Errorf("got requests %v, want %v", methods, want)
+	}
+}
+
+func TestNode_Market(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	node.AddOrder("alice", "1.000 SBD", "2.000 STEEM")
+	node.AddOrder("bob", "3.000 STEEM", "1.000 SBD")
+	node.AddOrder("carol", "1.000 SBD", "1.000 STEEM")
+	node.AddOrder("dave", "2.000 STEEM", "1.000 SBD")
+	node.AddFeedPrice("0.500 SBD", "1.000 STEEM")
+	node.AddFeedPrice("0.250 SBD", "1.000 STEEM")
+	a := api.NewAPI(node.URL)
+
+	book, err := a.GetOrderBook(10)
+	if err != nil {
+		t.Fatalf("GetOrderBook failed: %v", err)
+	}
+	if len(book.Bids) != 2 || book.Bids[0].OrderPrice != (protocolapi.OrderPrice{Base: "1.000 SBD", Quote: "1.000 STEEM"}) {
+		t.Errorf("unexpected bids %+v", book.Bids)
+	}
+	if len(book.Asks) != 2 || book.Asks[0].OrderPrice != (protocolapi.OrderPrice{Base: "3.000 STEEM", Quote: "1.000 SBD"}) {
+		t.Errorf("unexpected asks %+v", book.Asks)
+	}
+	var named protocolapi.OrderBook
+	if err := a.CallNamed("database_api", "get_order_book", map[string]interface{}{"limit": 1}, &named); err != nil {
+		t.Fatalf("database_api.get_order_book failed: %v", err)
+	}
+	if len(named.Bids) != 1 || len(named.Asks) != 1 {
+		t.Errorf("expected the limit on each side, got %+v", named)
+	}
+
+	feed, err := a.GetFeedHistory()
+	if err != nil {
+		t.Fatalf("GetFeedHistory failed: %v", err)
+	}
+	if len(feed.PriceHistory) != 2 || feed.PriceHistory[1].Base != "0.250 SBD" {
+		t.Errorf("unexpected feed history %+v", feed)
+	}
+}
+
+func TestNode_Faults(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+	node.AdvanceBlocks(3)
+	a := api.NewAPI(node.URL, api.WithMaxRetry(0))
+
+	node.SetCallError("condenser_api.get_block", []interface{}{2}, -32000, "boom")
+	if _, err := a.GetBlock(2); err == nil {
+		t.Error("expected get_block 2 to fail")
+	}
+	if _, err := a.GetBlock(3); err != nil {
+		t.Errorf("get_block 3 failed: %v", err)
+	}
+	node.ClearError("condenser_api.get_block")
+
+	node.FailHTTP(502, 1)
+	if _, err := a.GetBlock(2); err == nil {
+		t.Error("expected an HTTP error")
+	}
+	node.DropAnswers(1)
+	if _, err := a.GetBlock(2); err == nil {
+		t.Error("expected the connection to be dropped")
+	}
+	if _, err := a.GetBlock(2); err != nil {
+		t.Errorf("get_block failed after the faults: %v", err)
+	}
+	// The HTTP failure never reached the node; the dropped answer did.
+	if posts, calls := node.Posts(), len(node.Requests()); posts != 5 || calls != 4 {
+		t.Errorf("got %d posts and %d calls, want 5 and 4", posts, calls)
+	}
+
+	node.SetLatency(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := a.GetBlockContext(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to pass, got %v", err)
+	}
+}
+
+func TestNode_Batches(t *testing.T) {
+	node := NewNode(WithReversedBatches(), WithMaxBatchSize(2))
+	defer node.Close()
+	node.AdvanceBlocks(3)
+	a := api.NewAPI(node.URL, api.WithMaxRetry(0))
+
+	ctx := api.ContextWithHeader(context.Background(), "X-Trace-Id", "t1")
+	var b1, b2 protocolapi.Block
+	err := a.NewBatch().
+		Add("condenser_api", "get_block", []interface{}{1}, &b1).
+		Add("condenser_api", "get_block", []interface{}{2}, &b2).
+		DoContext(ctx)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if want, _ := node.Block(2); b2.BlockId != want.ID {
+		t.Errorf("got block %s, want %s", b2.BlockId, want.ID)
+	}
+	if got := node.Requests()[0].Header.Get("X-Trace-Id"); got != "t1" {
+		t.Errorf("expected the request header, got %q", got)
+	}
+
+	err = a.NewBatch().
+		Add("condenser_api", "get_block", []interface{}{1}, nil).
+		Add("condenser_api", "get_block", []interface{}{2}, nil).
+		Add("condenser_api", "get_block", []interface{}{3}, nil).
+		Do()
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit of 2") {
+		t.Errorf("expected the batch to be rejected, got %v", err)
+	}
+	if got := len(node.Requests()); got != 2 {
+		t.Errorf("a rejected batch must not be handled, got %d calls", got)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
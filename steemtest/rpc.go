@@ -0,0 +1,226 @@
+package steemtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errInvalidOp rejects an operation that is not a [type, value] pair.
+var errInvalidOp = errors.New("operation must be a [type, value] array")
+
+// rpcError is a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func invalidParams(message string) *rpcError {
+	return &rpcError{Code: -32602, Message: "Invalid parameters: " + message}
+}
+
+// assertFailed mimics steemd's report of a failed FC_ASSERT.
+func assertFailed(message string) *rpcError {
+	return &rpcError{Code: -32000, Message: message}
+}
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// ServeHTTP answers a JSON-RPC 2.0 request or batch. NewNode already serves
+// the node at URL; ServeHTTP lets a test mount it on its own server too.
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n.mu.Lock()
+	n.posts++
+	latency, status, drop := n.latency, 0, false
+	if n.failPosts > 0 {
+		n.failPosts--
+		status = n.failStatus
+	} else if n.dropPosts > 0 {
+		n.dropPosts--
+		drop = true
+	}
+	n.mu.Unlock()
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		case <-n.closed:
+			return
+		}
+	}
+
+	var out interface{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(trimmed, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		out = n.handleBatch(reqs, r.Header)
+	} else {
+		var req rpcRequest
+		if err := json.Unmarshal(trimmed, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		out = n.handle(&req, r.Header)
+	}
+	if drop {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+			}
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// steemd leaves <, > and & in messages and results unescaped.
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	e.Encode(out)
+}
+
+// handleBatch answers the calls of a batch, or rejects the whole batch when
+// it is larger than WithMaxBatchSize allows.
+func (n *Node) handleBatch(reqs []rpcRequest, header http.Header) interface{} {
+	n.mu.Lock()
+	max, reverse := n.maxBatchSize, n.reverseBatches
+	n.mu.Unlock()
+	if max > 0 && len(reqs) > max {
+		return &rpcResponse{JsonRpc: "2.0", ID: json.RawMessage("null"), Error: &rpcError{
+			Code:    -32600,
+			Message: fmt.Sprintf("batch of %d calls exceeds the limit of %d", len(reqs), max),
+		}}
+	}
+	resps := make([]*rpcResponse, len(reqs))
+	for i := range reqs {
+		resps[i] = n.handle(&reqs[i], header)
+	}
+	if reverse {
+		for i, j := 0, len(resps)-1; i < j; i, j = i+1, j-1 {
+			resps[i], resps[j] = resps[j], resps[i]
+		}
+	}
+	return resps
+}
+
+// handle records and answers one call.
+func (n *Node) handle(req *rpcRequest, header http.Header) *rpcResponse {
+	method, params, legacy := req.Method, req.Params, false
+	if method == "call" {
+		// Legacy form: [api, method, params].
+		var call []json.RawMessage
+		var apiName, name string
+		if json.Unmarshal(params, &call) != nil || len(call) != 3 ||
+			json.Unmarshal(call[0], &apiName) != nil || json.Unmarshal(call[1], &name) != nil {
+			return &rpcResponse{JsonRpc: "2.0", ID: req.ID, Error: invalidParams("call takes [api, method, params]")}
+		}
+		method, params, legacy = apiName+"."+name, call[2], true
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests = append(n.requests, Request{Method: method, Params: params, Legacy: legacy, Header: header.Clone()})
+
+	resp := &rpcResponse{JsonRpc: "2.0", ID: req.ID}
+	if failure := n.failure(method, params); failure != nil {
+		resp.Error = failure
+		return resp
+	}
+	handler, ok := methods[method]
+	if !ok {
+		resp.Error = &rpcError{Code: -32601, Message: "Could not find method " + method}
+		return resp
+	}
+	result, rpcErr := handler(n, params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+	// A nil result is sent as null, as steemd does for a missing block.
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	e.Encode(result)
+	resp.Result = bytes.TrimSpace(buf.Bytes())
+	return resp
+}
+
+// failure returns the error set by SetError or SetCallError for a call, the
+// more specific one first.
+func (n *Node) failure(method string, params json.RawMessage) *rpcError {
+	var decoded interface{}
+	json.Unmarshal(params, &decoded)
+	var every *rpcError
+	for _, f := range n.failures {
+		if f.method != method {
+			continue
+		}
+		if f.params == nil {
+			every = f.err
+		} else if reflect.DeepEqual(f.params, decoded) {
+			return f.err
+		}
+	}
+	return every
+}
+
+// positional decodes the condenser_api params array into args. The first
+// required elements must be present; later ones are optional.
+func positional(params json.RawMessage, required int, args ...interface{}) *rpcError {
+	var raw []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return invalidParams("params must be an array")
+		}
+	}
+	if len(raw) < required || len(raw) > len(args) {
+		return invalidParams("wrong number of params")
+	}
+	for i, value := range raw {
+		if err := json.Unmarshal(value, args[i]); err != nil {
+			return invalidParams(err.Error())
+		}
+	}
+	return nil
+}
+
+// named decodes the database_api params object into args.
+func named(params json.RawMessage, args interface{}) *rpcError {
+	if len(params) == 0 || params[0] != '{' {
+		return invalidParams("params must be an object")
+	}
+	if err := json.Unmarshal(params, args); err != nil {
+		return invalidParams(err.Error())
+	}
+	return nil
+}